@@ -2,12 +2,28 @@ package goagi
 
 import (
 	"bufio"
+	"errors"
+	"io"
+	"os"
 	"strings"
+	"time"
 )
 
 // ErrAGI goagi error
 var ErrAGI = newError("AGI session")
 
+// ErrSetup AGI session setup (environment) error
+var ErrSetup = newError("AGI setup")
+
+// ErrCommand AGI command failed or returned unexpected result
+var ErrCommand = newError("AGI command")
+
+// ErrArgument invalid argument of the command helper
+var ErrArgument = newError("Invalid argument")
+
+// ErrHangup channel hung up while helper was running
+var ErrHangup = newError("Channel hangup")
+
 // Reader interface for AGI object. Can be net.Conn, os.File or crafted
 type Reader interface {
 	Read(b []byte) (int, error)
@@ -32,6 +48,55 @@ type AGI struct {
 	writer   Writer
 	isHUP    bool
 	debugger Debugger
+	strict   bool
+	maxLines int
+	maxBytes int
+	timeout  time.Duration
+	catalog  *Catalog
+	lang     string
+	speaker  *Speaker
+}
+
+// Option configures AGI object created with New
+type Option func(*AGI)
+
+const (
+	// DefaultSetupMaxLines is default limit of session setup lines
+	DefaultSetupMaxLines = 256
+	// DefaultSetupMaxBytes is default limit of session setup size in bytes
+	DefaultSetupMaxBytes = 64 * 1024
+)
+
+// WithStrictSetup makes New fail when session setup contains malformed lines.
+// By default malformed lines are ignored.
+func WithStrictSetup() Option {
+	return func(agi *AGI) { agi.strict = true }
+}
+
+// WithSetupLimit sets maximum number of lines and size in bytes of the
+// session setup block that is accepted from the peer. Zero or negative
+// value keeps the default limit.
+func WithSetupLimit(lines, bytes int) Option {
+	return func(agi *AGI) {
+		if lines > 0 {
+			agi.maxLines = lines
+		}
+		if bytes > 0 {
+			agi.maxBytes = bytes
+		}
+	}
+}
+
+// WithSetupTimeout limits time to read session setup block when reader
+// supports read deadlines, like net.Conn. Deadline is cleared after setup.
+// Zero or negative value disables the limit.
+func WithSetupTimeout(d time.Duration) Option {
+	return func(agi *AGI) { agi.timeout = d }
+}
+
+// deadliner is a reader with read deadline, like net.Conn
+type deadliner interface {
+	SetReadDeadline(t time.Time) error
 }
 
 const (
@@ -64,19 +129,34 @@ Parameters:
 - Writer that implements Write method
 
 - Debugger that allows to deep library debugging. Nil for production.
+
+- Options that change session setup parsing. See WithStrictSetup, WithSetupLimit
+and WithSetupTimeout.
+
+Setup limit and timeout errors match ErrSetup.
 */
-func New(r Reader, w Writer, dbg Debugger) (*AGI, error) {
+func New(r Reader, w Writer, dbg Debugger, opts ...Option) (*AGI, error) {
 	agi := &AGI{
 		reader:   r,
 		writer:   w,
 		debugger: dbg,
+		maxLines: DefaultSetupMaxLines,
+		maxBytes: DefaultSetupMaxBytes,
+	}
+	for _, opt := range opts {
+		opt(agi)
 	}
 	agi.dbg("[>] New AGI")
 	sessData, err := agi.sessionInit()
+	if errors.Is(err, ErrSetup) {
+		return nil, err
+	}
 	if err != nil {
 		return nil, ErrAGI.Msg("Failed to read setup: %s", err)
 	}
-	agi.sessionSetup(sessData)
+	if err := agi.sessionSetup(sessData); err != nil && agi.strict {
+		return nil, err
+	}
 	return agi, nil
 }
 
@@ -106,25 +186,62 @@ func (agi *AGI) IsHungup() bool {
 	return agi.isHUP
 }
 
-func (agi *AGI) sessionInit() ([]string, error) {
+func (agi *AGI) sessionInit() (data []string, err error) {
 	agi.dbg("[>] sessionInit")
-	buf := bufio.NewReader(agi.reader)
+	if conn, ok := agi.reader.(deadliner); ok && agi.timeout > 0 {
+		if err := conn.SetReadDeadline(time.Now().Add(agi.timeout)); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				err = ErrSetup.Msg("setup timeout after %s", agi.timeout)
+			}
+			if derr := conn.SetReadDeadline(time.Time{}); derr != nil && err == nil {
+				err = derr
+			}
+		}()
+	}
+	return agi.readSetup()
+}
+
+func (agi *AGI) readSetup() ([]string, error) {
+	maxLines, maxBytes := agi.setupLimits()
+	limited := &io.LimitedReader{R: agi.reader, N: int64(maxBytes)}
+	buf := bufio.NewReader(limited)
 	data := make([]string, 0)
 
 	for {
 		line, err := buf.ReadString('\n')
 		if err != nil {
+			if errors.Is(err, io.EOF) && limited.N <= 0 {
+				return nil, ErrSetup.Msg("setup exceeds %d bytes", maxBytes)
+			}
 			return nil, err
 		}
-		if line == "\n" {
+		line = strings.TrimSuffix(line[:len(line)-1], "\r")
+		if line == "" {
 			break
 		}
+		if len(data) == maxLines {
+			return nil, ErrSetup.Msg("setup exceeds %d lines", maxLines)
+		}
 		agi.dbg(" [v] read line: %q", line)
-		data = append(data, line[:len(line)-1])
+		data = append(data, line)
 	}
 	return data, nil
 }
 
+func (agi *AGI) setupLimits() (int, int) {
+	lines, bytes := agi.maxLines, agi.maxBytes
+	if lines <= 0 {
+		lines = DefaultSetupMaxLines
+	}
+	if bytes <= 0 {
+		bytes = DefaultSetupMaxBytes
+	}
+	return lines, bytes
+}
+
 func (agi *AGI) dbg(pattern string, vargs ...interface{}) {
 	if agi.debugger != nil {
 		pattern += "\n"
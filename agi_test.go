@@ -2,6 +2,7 @@ package goagi
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"io/ioutil"
 	"log"
@@ -9,6 +10,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -102,6 +104,87 @@ func TestNewNetPipe(t *testing.T) {
 	assert.Equal(t, 2, len(agi.EnvArgs()))
 }
 
+func TestNewStrictSetup(t *testing.T) {
+	input := strings.Join(agiSetupInput, "\n")
+	input += "\nagi_foo bar\nfoo: bar\n\n"
+
+	agi, err := New(&stubReader{strings.NewReader(input)}, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2222", agi.Env("extension"))
+
+	agi, err = New(&stubReader{strings.NewReader(input)}, nil, nil, WithStrictSetup())
+	assert.Nil(t, agi)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "2 invalid line(s)")
+	assert.Contains(t, err.Error(), `"agi_foo bar"`)
+	assert.Contains(t, err.Error(), `"foo: bar"`)
+}
+
+func TestNewSetupCRLF(t *testing.T) {
+	input := strings.Join(agiSetupInput, "\r\n")
+	input += "\r\n\r\n"
+
+	agi, err := New(&stubReader{strings.NewReader(input)}, nil, nil, WithStrictSetup())
+	assert.Nil(t, err)
+	assert.Equal(t, "SIP/2222@default-00000023", agi.Env("channel"))
+	assert.Equal(t, []string{"argument1", "argument2"}, agi.EnvArgs())
+}
+
+func TestNewSetupLimit(t *testing.T) {
+	input := strings.Join(agiSetupInput, "\n")
+	input += "\n\n"
+
+	agi, err := New(&stubReader{strings.NewReader(input)}, nil, nil, WithSetupLimit(10, 0))
+	assert.Nil(t, agi)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "exceeds 10 lines")
+	assert.True(t, errors.Is(err, ErrSetup))
+
+	agi, err = New(&stubReader{strings.NewReader(input)}, nil, nil, WithSetupLimit(0, 100))
+	assert.Nil(t, agi)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "exceeds 100 bytes")
+	assert.True(t, errors.Is(err, ErrSetup))
+
+	agi, err = New(&stubReader{strings.NewReader(input)}, nil, nil,
+		WithSetupLimit(len(agiSetupInput), len(input)))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(agi.EnvArgs()))
+
+	// endless line without new line symbol
+	long := &stubReader{strings.NewReader("agi_foo: " + strings.Repeat("x", DefaultSetupMaxBytes))}
+	agi, err = New(long, nil, nil)
+	assert.Nil(t, agi)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "exceeds 65536 bytes")
+}
+
+func TestNewSetupTimeout(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	agi, err := New(server, server, nil, WithSetupTimeout(10*time.Millisecond))
+	assert.Nil(t, agi)
+	assert.True(t, errors.Is(err, ErrSetup))
+	assert.Contains(t, err.Error(), "setup timeout after 10ms")
+
+	// deadline is cleared after setup
+	client, server = net.Pipe()
+	defer client.Close()
+	input := strings.Join(agiSetupInput, "\n") + "\n\n"
+	go func() {
+		client.Write([]byte(input))
+		buf := make([]byte, 64)
+		client.Read(buf)
+		time.Sleep(30 * time.Millisecond)
+		client.Write([]byte("200 result=1\n"))
+	}()
+	agi, err = New(server, server, nil, WithSetupTimeout(20*time.Millisecond))
+	assert.Nil(t, err)
+	resp, err := agi.Answer()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, resp.Result())
+}
+
 func TestSessionInitDeviceFail(t *testing.T) {
 	stdin, _, err := os.Pipe()
 	assert.Nil(t, err)
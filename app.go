@@ -0,0 +1,393 @@
+package goagi
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DialStatus is value of DIALSTATUS variable
+type DialStatus string
+
+// Dial statuses
+const (
+	DialAnswer      DialStatus = "ANSWER"
+	DialBusy        DialStatus = "BUSY"
+	DialNoAnswer    DialStatus = "NOANSWER"
+	DialCancel      DialStatus = "CANCEL"
+	DialCongestion  DialStatus = "CONGESTION"
+	DialChanUnavail DialStatus = "CHANUNAVAIL"
+	DialDontCall    DialStatus = "DONTCALL"
+	DialTorture     DialStatus = "TORTURE"
+	DialInvalidArgs DialStatus = "INVALIDARGS"
+)
+
+// QueueStatus is value of QUEUESTATUS variable
+type QueueStatus string
+
+// Queue statuses
+const (
+	QueueTimeout      QueueStatus = "TIMEOUT"
+	QueueFull         QueueStatus = "FULL"
+	QueueJoinEmpty    QueueStatus = "JOINEMPTY"
+	QueueLeaveEmpty   QueueStatus = "LEAVEEMPTY"
+	QueueJoinUnavail  QueueStatus = "JOINUNAVAIL"
+	QueueLeaveUnavail QueueStatus = "LEAVEUNAVAIL"
+	QueueContinue     QueueStatus = "CONTINUE"
+	QueueWithdraw     QueueStatus = "WITHDRAW"
+)
+
+// PlaybackStatus is value of PLAYBACKSTATUS and BACKGROUNDSTATUS variables
+type PlaybackStatus string
+
+// Playback statuses
+const (
+	PlaybackSuccess PlaybackStatus = "SUCCESS"
+	PlaybackFailed  PlaybackStatus = "FAILED"
+)
+
+// ConfBridgeResult is value of CONFBRIDGE_RESULT variable
+type ConfBridgeResult string
+
+// ConfBridge results
+const (
+	ConfBridgeFailed    ConfBridgeResult = "FAILED"
+	ConfBridgeHangup    ConfBridgeResult = "HANGUP"
+	ConfBridgeKicked    ConfBridgeResult = "KICKED"
+	ConfBridgeEndMarked ConfBridgeResult = "ENDMARKED"
+	ConfBridgeDTMF      ConfBridgeResult = "DTMF"
+	ConfBridgeTimeout   ConfBridgeResult = "TIMEOUT"
+)
+
+// TransferStatus is value of TRANSFERSTATUS variable
+type TransferStatus string
+
+// Transfer statuses
+const (
+	TransferSuccess     TransferStatus = "SUCCESS"
+	TransferFailure     TransferStatus = "FAILURE"
+	TransferUnsupported TransferStatus = "UNSUPPORTED"
+)
+
+// variable set by MixMonitor with recording ID
+const mixMonitorIDVar = "GOAGI_MIXMONITOR_ID"
+
+// appOptions builds application options string like "tTm(class)"
+type appOptions []string
+
+func (o *appOptions) flag(set bool, flag string) {
+	if set {
+		*o = append(*o, flag)
+	}
+}
+
+func (o *appOptions) arg(flag, arg string) {
+	if arg != "" {
+		*o = append(*o, flag+"("+argEscaper.Replace(arg)+")")
+	}
+}
+
+func (o appOptions) String() string { return strings.Join(o, "") }
+
+// appArgs joins application arguments with commas. Trailing empty
+// arguments are removed.
+func appArgs(args ...string) string {
+	for len(args) > 0 && args[len(args)-1] == "" {
+		args = args[:len(args)-1]
+	}
+	return strings.Join(args, ",")
+}
+
+// appArg escapes application argument value
+func appArg(arg string) string {
+	return argEscaper.Replace(arg)
+}
+
+// secArg formats duration as seconds argument or empty when not set
+func secArg(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return strconv.Itoa(durationSec(d))
+}
+
+// execApp executes application and checks it exists
+func (agi *AGI) execApp(app, args string) error {
+	if strings.ContainsAny(args, "\r\n") {
+		return ErrArgument.Msg("%s arguments have new line", app)
+	}
+	resp, err := checkResponse(agi.Exec(app, args))
+	if err != nil {
+		return err
+	}
+	if resp.Result() == -2 {
+		return ErrCommand.Msg("application %s not found", app)
+	}
+	return nil
+}
+
+// DialOptions arguments and options of Dial application
+type DialOptions struct {
+	// Timeout to wait for answer
+	Timeout time.Duration
+	// Ringing indicates ringing to caller (r)
+	Ringing bool
+	// MusicClass plays music on hold class to caller instead of ringing (m)
+	MusicClass string
+	// CalleeTransfer allows callee to transfer (t)
+	CalleeTransfer bool
+	// CallerTransfer allows caller to transfer (T)
+	CallerTransfer bool
+	// CalleeHangup allows callee to hang up with * (h)
+	CalleeHangup bool
+	// CallerHangup allows caller to hang up with * (H)
+	CallerHangup bool
+	// Announce file played to callee (A)
+	Announce string
+	// Limit call duration (L)
+	Limit time.Duration
+	// PreDial gosub "context^exten^priority" executed on callee channel (b)
+	PreDial string
+	// Gosub "context^exten^priority" executed on callee after answer (U)
+	Gosub string
+	// Options raw option letters appended to options
+	Options string
+	// URL sent to callee if supported
+	URL string
+}
+
+// DialResult outcome of Dial read from status variables
+type DialResult struct {
+	Status       DialStatus
+	PeerName     string
+	AnsweredTime time.Duration
+	DialedTime   time.Duration
+}
+
+// Answered returns true if dialed call was answered
+func (r *DialResult) Answered() bool { return r.Status == DialAnswer }
+
+/*
+Dial dials targets with Dial application and returns outcome from
+DIALSTATUS, DIALEDPEERNAME, ANSWEREDTIME and DIALEDTIME variables.
+Multiple targets are dialed simultaneously.
+
+	res, err := agi.Dial([]string{"PJSIP/100"}, goagi.DialOptions{
+		Timeout: 30 * time.Second, CalleeTransfer: true, CallerTransfer: true})
+*/
+func (agi *AGI) Dial(targets []string, opts DialOptions) (*DialResult, error) {
+	if len(targets) == 0 {
+		return nil, ErrArgument.Msg("no dial targets")
+	}
+	escaped := make([]string, len(targets))
+	for i, target := range targets {
+		escaped[i] = appArg(target)
+	}
+
+	var o appOptions
+	o.flag(opts.Ringing, "r")
+	o.arg("m", opts.MusicClass)
+	o.flag(opts.CalleeTransfer, "t")
+	o.flag(opts.CallerTransfer, "T")
+	o.flag(opts.CalleeHangup, "h")
+	o.flag(opts.CallerHangup, "H")
+	o.arg("A", opts.Announce)
+	if opts.Limit > 0 {
+		o.arg("L", strconv.FormatInt(opts.Limit.Milliseconds(), 10))
+	}
+	o.arg("b", opts.PreDial)
+	o.arg("U", opts.Gosub)
+	o = append(o, opts.Options)
+
+	args := appArgs(strings.Join(escaped, "&"), secArg(opts.Timeout),
+		o.String(), appArg(opts.URL))
+	if err := agi.execApp("Dial", args); err != nil {
+		return nil, err
+	}
+
+	vars, err := agi.GetVariables("DIALSTATUS", "DIALEDPEERNAME",
+		"ANSWEREDTIME", "DIALEDTIME")
+	if err != nil {
+		return nil, err
+	}
+	res := &DialResult{
+		Status:   DialStatus(vars["DIALSTATUS"]),
+		PeerName: vars["DIALEDPEERNAME"],
+	}
+	res.AnsweredTime, _ = parseDuration(vars["ANSWEREDTIME"])
+	res.DialedTime, _ = parseDuration(vars["DIALEDTIME"])
+	return res, nil
+}
+
+// QueueOptions arguments and options of Queue application
+type QueueOptions struct {
+	// Timeout to wait in queue
+	Timeout time.Duration
+	// Ringing indicates ringing to caller instead of music on hold (r)
+	Ringing bool
+	// Continue dialplan when callee hangs up (c)
+	Continue bool
+	// CalleeTransfer allows agent to transfer (t)
+	CalleeTransfer bool
+	// CallerTransfer allows caller to transfer (T)
+	CallerTransfer bool
+	// Announce file played to agent overriding queue announce
+	Announce string
+	// Gosub executed on agent channel after answer
+	Gosub string
+	// Rule is penalty rule name
+	Rule string
+	// Position of the caller in queue, 0 adds caller to the end
+	Position int
+	// Options raw option letters appended to options
+	Options string
+}
+
+/*
+Queue places call in queue and returns QUEUESTATUS. Asterisk sets
+status when caller leaves queue without being answered, so empty
+status means that call was connected to agent.
+*/
+func (agi *AGI) Queue(queue string, opts QueueOptions) (QueueStatus, error) {
+	var o appOptions
+	o.flag(opts.Ringing, "r")
+	o.flag(opts.Continue, "c")
+	o.flag(opts.CalleeTransfer, "t")
+	o.flag(opts.CallerTransfer, "T")
+	o = append(o, opts.Options)
+
+	position := ""
+	if opts.Position > 0 {
+		position = strconv.Itoa(opts.Position)
+	}
+	args := appArgs(appArg(queue), o.String(), "", appArg(opts.Announce),
+		secArg(opts.Timeout), "", "", appArg(opts.Gosub), appArg(opts.Rule), position)
+	if err := agi.execApp("Queue", args); err != nil {
+		return "", err
+	}
+	val, _, err := agi.Variable("QUEUESTATUS")
+	return QueueStatus(val), err
+}
+
+// PlaybackOptions options of Playback and Background applications
+type PlaybackOptions struct {
+	// Skip playback if channel is not answered (s)
+	Skip bool
+	// NoAnswer plays without answering channel (n)
+	NoAnswer bool
+	// Language overrides channel language (Background only)
+	Language string
+	// Context for extension match on digit (Background only)
+	Context string
+}
+
+// Playback plays files with Playback application and returns PLAYBACKSTATUS
+func (agi *AGI) Playback(files []string, opts PlaybackOptions) (PlaybackStatus, error) {
+	var o appOptions
+	o.flag(opts.Skip, "skip")
+	o.flag(opts.NoAnswer, "noanswer")
+	if err := agi.execApp("Playback", appArgs(playFiles(files), strings.Join(o, "&"))); err != nil {
+		return "", err
+	}
+	val, _, err := agi.Variable("PLAYBACKSTATUS")
+	return PlaybackStatus(val), err
+}
+
+// Background plays files with Background application and returns
+// BACKGROUNDSTATUS. Pressed digit continues execution in dialplan after
+// AGI exits, use StreamFile to get digit in AGI.
+func (agi *AGI) Background(files []string, opts PlaybackOptions) (PlaybackStatus, error) {
+	var o appOptions
+	o.flag(opts.Skip, "s")
+	o.flag(opts.NoAnswer, "n")
+	args := appArgs(playFiles(files), o.String(), appArg(opts.Language),
+		appArg(opts.Context))
+	if err := agi.execApp("Background", args); err != nil {
+		return "", err
+	}
+	val, _, err := agi.Variable("BACKGROUNDSTATUS")
+	return PlaybackStatus(val), err
+}
+
+func playFiles(files []string) string {
+	escaped := make([]string, len(files))
+	for i, file := range files {
+		escaped[i] = appArg(file)
+	}
+	return strings.Join(escaped, "&")
+}
+
+// MixMonitorOptions options of MixMonitor application
+type MixMonitorOptions struct {
+	// Append to existing file (a)
+	Append bool
+	// Bridged records only when channel is bridged (b)
+	Bridged bool
+	// ReadVolume adjusts heard volume by factor -4..4 (v)
+	ReadVolume int
+	// WriteVolume adjusts spoken volume by factor -4..4 (V)
+	WriteVolume int
+	// ReadFile records received audio to separate file (r)
+	ReadFile string
+	// WriteFile records sent audio to separate file (t)
+	WriteFile string
+	// Command executed when recording ends
+	Command string
+}
+
+// MixMonitor starts recording of the channel and returns recording ID
+// that can be used to stop it with StopMixMonitor
+func (agi *AGI) MixMonitor(file string, opts MixMonitorOptions) (string, error) {
+	var o appOptions
+	o.flag(opts.Append, "a")
+	o.flag(opts.Bridged, "b")
+	if opts.ReadVolume != 0 {
+		o.arg("v", strconv.Itoa(opts.ReadVolume))
+	}
+	if opts.WriteVolume != 0 {
+		o.arg("V", strconv.Itoa(opts.WriteVolume))
+	}
+	o.arg("r", opts.ReadFile)
+	o.arg("t", opts.WriteFile)
+	o.arg("i", mixMonitorIDVar)
+
+	if err := agi.execApp("MixMonitor", appArgs(appArg(file), o.String(),
+		appArg(opts.Command))); err != nil {
+		return "", err
+	}
+	id, _, err := agi.Variable(mixMonitorIDVar)
+	return id, err
+}
+
+// StopMixMonitor stops recording by ID or all recordings when id is empty
+func (agi *AGI) StopMixMonitor(id string) error {
+	return agi.execApp("StopMixMonitor", appArg(id))
+}
+
+// ConfBridgeOptions profiles of ConfBridge application
+type ConfBridgeOptions struct {
+	BridgeProfile string
+	UserProfile   string
+	Menu          string
+}
+
+// ConfBridge enters conference and returns CONFBRIDGE_RESULT when
+// channel leaves conference
+func (agi *AGI) ConfBridge(conference string, opts ConfBridgeOptions) (ConfBridgeResult, error) {
+	args := appArgs(appArg(conference), appArg(opts.BridgeProfile),
+		appArg(opts.UserProfile), appArg(opts.Menu))
+	if err := agi.execApp("ConfBridge", args); err != nil {
+		return "", err
+	}
+	val, _, err := agi.Variable("CONFBRIDGE_RESULT")
+	return ConfBridgeResult(val), err
+}
+
+// Transfer transfers channel to destination and returns TRANSFERSTATUS
+func (agi *AGI) Transfer(dest string) (TransferStatus, error) {
+	if err := agi.execApp("Transfer", appArg(dest)); err != nil {
+		return "", err
+	}
+	val, _, err := agi.Variable("TRANSFERSTATUS")
+	return TransferStatus(val), err
+}
@@ -0,0 +1,129 @@
+package goagi
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppArgs(t *testing.T) {
+	assert.Equal(t, "a,,c", appArgs("a", "", "c", "", ""))
+	assert.Equal(t, "", appArgs("", ""))
+	assert.Equal(t, `file\,name`, appArg("file,name"))
+
+	var o appOptions
+	o.flag(true, "t")
+	o.flag(false, "T")
+	o.arg("m", "default")
+	o.arg("A", "")
+	o.arg("U", "sub^s^1(a,b)")
+	assert.Equal(t, `tm(default)U(sub^s^1\(a\,b\))`, o.String())
+}
+
+func TestDial(t *testing.T) {
+	agi, buf := mockAGIScript("200 result=0",
+		"200 result=1 (ANSWER)", "200 result=1 (PJSIP/100-00000002)",
+		"200 result=1 (42)", "200 result=1 (50)")
+	res, err := agi.Dial([]string{"PJSIP/100", "PJSIP/200"}, DialOptions{
+		Timeout:        30 * time.Second,
+		Ringing:        true,
+		CalleeTransfer: true,
+		CallerTransfer: true,
+		Limit:          time.Minute,
+		PreDial:        "predial^s^1",
+	})
+	assert.Nil(t, err)
+	assert.True(t, res.Answered())
+	assert.Equal(t, &DialResult{DialAnswer, "PJSIP/100-00000002",
+		42 * time.Second, 50 * time.Second}, res)
+	assert.Equal(t,
+		`EXEC Dial "PJSIP/100&PJSIP/200,30,rtTL(60000)b(predial^s^1)"`+"\n"+
+			"GET VARIABLE DIALSTATUS\nGET VARIABLE DIALEDPEERNAME\n"+
+			"GET VARIABLE ANSWEREDTIME\nGET VARIABLE DIALEDTIME\n",
+		buf.String())
+
+	agi, buf = mockAGIScript("200 result=0", "200 result=1 (BUSY)",
+		"200 result=0", "200 result=0", "200 result=1 (3)")
+	res, err = agi.Dial([]string{"SIP/trunk/5551234"}, DialOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, DialBusy, res.Status)
+	assert.False(t, res.Answered())
+	assert.Contains(t, buf.String(), `EXEC Dial "SIP/trunk/5551234"`+"\n")
+
+	_, err = agi.Dial(nil, DialOptions{})
+	assert.True(t, errors.Is(err, ErrArgument))
+
+	agi, _ = mockAGI("200 result=-2")
+	_, err = agi.Dial([]string{"PJSIP/100"}, DialOptions{})
+	assert.True(t, errors.Is(err, ErrCommand))
+}
+
+func TestQueue(t *testing.T) {
+	agi, buf := mockAGIScript("200 result=0", "200 result=1 (TIMEOUT)")
+	status, err := agi.Queue("support", QueueOptions{
+		Timeout: 2 * time.Minute, Continue: true, Rule: "vip", Position: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, QueueTimeout, status)
+	assert.Equal(t, `EXEC Queue "support,c,,,120,,,,vip,1"`+"\n"+
+		"GET VARIABLE QUEUESTATUS\n", buf.String())
+
+	agi, _ = mockAGIScript("200 result=0", "200 result=0")
+	status, err = agi.Queue("support", QueueOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, QueueStatus(""), status)
+}
+
+func TestPlaybackApps(t *testing.T) {
+	agi, buf := mockAGIScript("200 result=0", "200 result=1 (SUCCESS)")
+	status, err := agi.Playback([]string{"hello-world", "tt-monkeys"},
+		PlaybackOptions{Skip: true, NoAnswer: true})
+	assert.Nil(t, err)
+	assert.Equal(t, PlaybackSuccess, status)
+	assert.Equal(t, `EXEC Playback "hello-world&tt-monkeys,skip&noanswer"`+"\n"+
+		"GET VARIABLE PLAYBACKSTATUS\n", buf.String())
+
+	agi, buf = mockAGIScript("200 result=0", "200 result=1 (FAILED)")
+	status, err = agi.Background([]string{"welcome"},
+		PlaybackOptions{NoAnswer: true, Context: "ivr"})
+	assert.Nil(t, err)
+	assert.Equal(t, PlaybackFailed, status)
+	assert.Equal(t, `EXEC Background "welcome,n,,ivr"`+"\n"+
+		"GET VARIABLE BACKGROUNDSTATUS\n", buf.String())
+}
+
+func TestMixMonitor(t *testing.T) {
+	agi, buf := mockAGIScript("200 result=0", "200 result=1 (0x7f3c)")
+	id, err := agi.MixMonitor("/var/spool/rec/call.wav", MixMonitorOptions{
+		Bridged: true, ReadVolume: 2, Command: "/bin/post ^{MIXMONITOR_FILENAME}"})
+	assert.Nil(t, err)
+	assert.Equal(t, "0x7f3c", id)
+	assert.Equal(t,
+		`EXEC MixMonitor "/var/spool/rec/call.wav,bv(2)i(GOAGI_MIXMONITOR_ID),/bin/post ^{MIXMONITOR_FILENAME}"`+"\n"+
+			"GET VARIABLE GOAGI_MIXMONITOR_ID\n", buf.String())
+
+	agi, buf = mockAGI("200 result=0")
+	assert.Nil(t, agi.StopMixMonitor(id))
+	assert.Equal(t, `EXEC StopMixMonitor "0x7f3c"`+"\n", buf.String())
+}
+
+func TestConfBridgeTransfer(t *testing.T) {
+	agi, buf := mockAGIScript("200 result=0", "200 result=1 (KICKED)")
+	res, err := agi.ConfBridge("1001", ConfBridgeOptions{UserProfile: "admin"})
+	assert.Nil(t, err)
+	assert.Equal(t, ConfBridgeKicked, res)
+	assert.Equal(t, `EXEC ConfBridge "1001,,admin"`+"\n"+
+		"GET VARIABLE CONFBRIDGE_RESULT\n", buf.String())
+
+	agi, buf = mockAGIScript("200 result=0", "200 result=1 (UNSUPPORTED)")
+	status, err := agi.Transfer("PJSIP/200")
+	assert.Nil(t, err)
+	assert.Equal(t, TransferUnsupported, status)
+	assert.Equal(t, `EXEC Transfer "PJSIP/200"`+"\n"+
+		"GET VARIABLE TRANSFERSTATUS\n", buf.String())
+
+	agi, _ = mockAGI("200 result=0")
+	_, err = agi.Transfer("PJSIP/200\nHANGUP")
+	assert.True(t, errors.Is(err, ErrArgument))
+}
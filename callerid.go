@@ -0,0 +1,227 @@
+package goagi
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Presentation is calling presentation code: restriction bits combined
+// with screening indicator
+type Presentation int
+
+// Calling presentation codes
+const (
+	PresAllowedNotScreened    Presentation = 0x00
+	PresAllowedPassedScreen   Presentation = 0x01
+	PresAllowedFailedScreen   Presentation = 0x02
+	PresAllowed               Presentation = 0x03
+	PresRestrictedNotScreened Presentation = 0x20
+	PresRestrictedPassed      Presentation = 0x21
+	PresRestrictedFailed      Presentation = 0x22
+	PresRestricted            Presentation = 0x23
+	PresUnavailable           Presentation = 0x43
+)
+
+// presentation masks
+const (
+	presRestrictionMask = 0x60
+	presScreeningMask   = 0x03
+	presRestricted      = 0x20
+	presUnavailable     = 0x40
+)
+
+// presentation names as used by CALLERID(pres) dialplan function
+var presNames = map[Presentation]string{
+	PresAllowedNotScreened:    "allowed_not_screened",
+	PresAllowedPassedScreen:   "allowed_passed_screen",
+	PresAllowedFailedScreen:   "allowed_failed_screen",
+	PresAllowed:               "allowed",
+	PresRestrictedNotScreened: "prohib_not_screened",
+	PresRestrictedPassed:      "prohib_passed_screen",
+	PresRestrictedFailed:      "prohib_failed_screen",
+	PresRestricted:            "prohib",
+	PresUnavailable:           "unavailable",
+}
+
+// ParsePresentation parses presentation code number or name like
+// "prohib_passed_screen"
+func ParsePresentation(s string) (Presentation, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 0 || n > 0x7f {
+			return 0, ErrArgument.Msg("invalid presentation %d", n)
+		}
+		return Presentation(n), nil
+	}
+	for pres, name := range presNames {
+		if strings.EqualFold(name, s) {
+			return pres, nil
+		}
+	}
+	return 0, ErrArgument.Msg("invalid presentation %q", s)
+}
+
+// String returns presentation name or number if code has no name
+func (p Presentation) String() string {
+	if name, ok := presNames[p]; ok {
+		return name
+	}
+	return strconv.Itoa(int(p))
+}
+
+// Allowed returns true if number presentation is allowed
+func (p Presentation) Allowed() bool { return p&presRestrictionMask == 0 }
+
+// Restricted returns true if number presentation is restricted
+func (p Presentation) Restricted() bool { return p&presRestrictionMask == presRestricted }
+
+// Unavailable returns true if number is not available
+func (p Presentation) Unavailable() bool { return p&presRestrictionMask == presUnavailable }
+
+// Screening returns screening indicator: 0 not screened, 1 passed,
+// 2 failed, 3 network provided
+func (p Presentation) Screening() int { return int(p & presScreeningMask) }
+
+// CallerID is caller name, number and presentation
+type CallerID struct {
+	Name   string
+	Number string
+	Pres   Presentation
+}
+
+/*
+ParseCallerID parses Asterisk caller ID syntax:
+
+	"John \"JJ\" Doe" <5551234>
+	John Doe <5551234>
+	<5551234>
+	5551234
+	"John Doe"
+
+Text without angle brackets is a number when it has only dial string
+characters and a name otherwise.
+*/
+func ParseCallerID(s string) (CallerID, error) {
+	var cid CallerID
+	s = strings.TrimSpace(s)
+
+	if strings.HasPrefix(s, `"`) {
+		name, rest, err := unquoteName(s)
+		if err != nil {
+			return cid, err
+		}
+		cid.Name = name
+		s = strings.TrimSpace(rest)
+		if s == "" {
+			return cid, nil
+		}
+	}
+
+	if lt := strings.LastIndexByte(s, '<'); lt >= 0 {
+		gt := strings.IndexByte(s[lt:], '>')
+		if gt < 0 {
+			return cid, ErrArgument.Msg("caller ID %q has no closing '>'", s)
+		}
+		if name := strings.TrimSpace(s[:lt]); name != "" {
+			if cid.Name != "" {
+				return cid, ErrArgument.Msg("invalid caller ID %q", s)
+			}
+			cid.Name = name
+		}
+		cid.Number = strings.TrimSpace(s[lt+1 : lt+gt])
+		return cid, nil
+	}
+
+	if cid.Name != "" {
+		return cid, ErrArgument.Msg("invalid caller ID number %q", s)
+	}
+	if isDialNumber(s) {
+		cid.Number = s
+	} else {
+		cid.Name = s
+	}
+	return cid, nil
+}
+
+// unquoteName returns name from quoted string with backslash escapes
+// and the rest of string
+func unquoteName(s string) (string, string, error) {
+	var name strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+			}
+			name.WriteByte(s[i])
+		case '"':
+			return name.String(), s[i+1:], nil
+		default:
+			name.WriteByte(s[i])
+		}
+	}
+	return "", "", ErrArgument.Msg("caller ID %q has unterminated name", s)
+}
+
+func isDialNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789*#+-(). ", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats caller ID as "name" <number>
+func (c CallerID) String() string {
+	name := ""
+	if c.Name != "" {
+		r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+		name = `"` + r.Replace(c.Name) + `"`
+	}
+	if c.Number == "" {
+		return name
+	}
+	if name == "" {
+		return "<" + c.Number + ">"
+	}
+	return name + " <" + c.Number + ">"
+}
+
+// CallerID returns caller ID of the channel from AGI environment.
+// Asterisk "unknown" values are returned as empty strings.
+func (agi *AGI) CallerID() CallerID {
+	known := func(val string) string {
+		if val == "unknown" {
+			return ""
+		}
+		return val
+	}
+	cid := CallerID{
+		Name:   known(agi.Env("calleridname")),
+		Number: known(agi.Env("callerid")),
+	}
+	cid.Pres, _ = ParsePresentation(agi.Env("callingpres"))
+	return cid
+}
+
+/*
+SetCallerID sets caller name and number of the channel with SET CALLERID
+and presentation with CALLERID(pres). Zero presentation
+(PresAllowedNotScreened) keeps presentation of the channel.
+*/
+func (agi *AGI) SetCallerID(cid CallerID) error {
+	if strings.ContainsAny(cid.Number, "<>\"\r\n") || strings.ContainsAny(cid.Name, "\r\n") {
+		return ErrArgument.Msg("invalid caller ID %q", cid.String())
+	}
+	if _, err := checkResponse(agi.SetCallerid(cid.String())); err != nil {
+		return err
+	}
+	if cid.Pres == PresAllowedNotScreened {
+		return nil
+	}
+	return agi.SetCallerIDItem(CallerIDPres, cid.Pres.String())
+}
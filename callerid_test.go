@@ -0,0 +1,114 @@
+package goagi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresentation(t *testing.T) {
+	assert.Equal(t, "allowed_not_screened", PresAllowedNotScreened.String())
+	assert.Equal(t, "prohib_passed_screen", PresRestrictedPassed.String())
+	assert.Equal(t, "unavailable", PresUnavailable.String())
+	assert.Equal(t, "65", Presentation(65).String())
+
+	assert.True(t, PresAllowed.Allowed())
+	assert.False(t, PresAllowed.Restricted())
+	assert.True(t, PresRestrictedFailed.Restricted())
+	assert.Equal(t, 2, PresRestrictedFailed.Screening())
+	assert.True(t, PresUnavailable.Unavailable())
+	assert.False(t, PresUnavailable.Allowed())
+	assert.Equal(t, 3, PresUnavailable.Screening())
+
+	tests := map[string]Presentation{
+		"0":                     PresAllowedNotScreened,
+		"35":                    PresRestricted,
+		" 67 ":                  PresUnavailable,
+		"prohib":                PresRestricted,
+		"Allowed_Passed_Screen": PresAllowedPassedScreen,
+	}
+	for in, want := range tests {
+		pres, err := ParsePresentation(in)
+		assert.Nil(t, err, in)
+		assert.Equal(t, want, pres, in)
+	}
+	for _, in := range []string{"", "-1", "200", "hidden"} {
+		_, err := ParsePresentation(in)
+		assert.True(t, errors.Is(err, ErrArgument), in)
+	}
+}
+
+func TestParseCallerID(t *testing.T) {
+	tests := []struct {
+		input string
+		want  CallerID
+	}{
+		{`"John Doe" <5551234>`, CallerID{Name: "John Doe", Number: "5551234"}},
+		{`"John \"JJ\" Doe" <+15551234>`, CallerID{Name: `John "JJ" Doe`, Number: "+15551234"}},
+		{`"Sales <main>" <100>`, CallerID{Name: "Sales <main>", Number: "100"}},
+		{`John Doe <5551234>`, CallerID{Name: "John Doe", Number: "5551234"}},
+		{`<5551234>`, CallerID{Number: "5551234"}},
+		{`5551234`, CallerID{Number: "5551234"}},
+		{`"John Doe"`, CallerID{Name: "John Doe"}},
+		{`John`, CallerID{Name: "John"}},
+		{``, CallerID{}},
+	}
+	for _, tc := range tests {
+		cid, err := ParseCallerID(tc.input)
+		assert.Nil(t, err, tc.input)
+		assert.Equal(t, tc.want, cid, tc.input)
+	}
+
+	for _, in := range []string{`"John <5551234>`, `John <5551234`, `"John" 5551234`} {
+		_, err := ParseCallerID(in)
+		assert.True(t, errors.Is(err, ErrArgument), in)
+	}
+}
+
+func TestCallerIDString(t *testing.T) {
+	assert.Equal(t, `"John Doe" <5551234>`, CallerID{Name: "John Doe", Number: "5551234"}.String())
+	assert.Equal(t, `"John \"JJ\" \\ Doe" <1>`, CallerID{Name: `John "JJ" \ Doe`, Number: "1"}.String())
+	assert.Equal(t, `<5551234>`, CallerID{Number: "5551234"}.String())
+	assert.Equal(t, `"John"`, CallerID{Name: "John"}.String())
+	assert.Equal(t, ``, CallerID{}.String())
+
+	cid := CallerID{Name: `A "B" <C> \ D`, Number: "+1555"}
+	parsed, err := ParseCallerID(cid.String())
+	assert.Nil(t, err)
+	assert.Equal(t, cid, parsed)
+}
+
+func TestAGICallerID(t *testing.T) {
+	agi := &AGI{env: map[string]string{
+		"callerid": "5001", "calleridname": "Alice", "callingpres": "33"}}
+	assert.Equal(t, CallerID{"Alice", "5001", PresRestrictedPassed}, agi.CallerID())
+
+	agi = &AGI{env: map[string]string{
+		"callerid": "unknown", "calleridname": "unknown", "callingpres": "67"}}
+	cid := agi.CallerID()
+	assert.Equal(t, CallerID{Pres: PresUnavailable}, cid)
+	assert.True(t, cid.Pres.Unavailable())
+}
+
+func TestSetCallerID(t *testing.T) {
+	agi, buf := mockAGI("200 result=1")
+	err := agi.SetCallerID(CallerID{Name: `John "JJ"`, Number: "5551234"})
+	assert.Nil(t, err)
+	assert.Equal(t, `SET CALLERID "\"John \\\"JJ\\\"\" <5551234>"`+"\n", buf.String())
+
+	agi, buf = mockAGIScript("200 result=1", "200 result=1")
+	err = agi.SetCallerID(CallerID{Number: "100", Pres: PresRestricted})
+	assert.Nil(t, err)
+	assert.Equal(t, "SET CALLERID \"<100>\"\n"+
+		"SET VARIABLE \"CALLERID(pres)\" \"prohib\"\n", buf.String())
+
+	agi, buf = mockAGI("200 result=1")
+	err = agi.SetCallerID(CallerID{Number: "100>\nHANGUP"})
+	assert.True(t, errors.Is(err, ErrArgument))
+	assert.Empty(t, buf.String())
+
+	agi, _ = mockAGI("511 Command Not Permitted on a dead channel")
+	err = agi.SetCallerID(CallerID{Number: "100"})
+	assert.True(t, errors.Is(err, ErrCommand))
+}
@@ -0,0 +1,180 @@
+package goagi
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// size of canonical PCM WAV header
+const wavHeaderSize = 44
+
+/*
+WAVWriter writes signed linear 16-bit 8kHz mono audio to WAV file.
+Header sizes are updated on Close.
+*/
+type WAVWriter struct {
+	w    io.WriteSeeker
+	size uint32
+}
+
+// NewWAVWriter writes WAV header and returns writer
+func NewWAVWriter(w io.WriteSeeker) (*WAVWriter, error) {
+	wav := &WAVWriter{w: w}
+	if err := wav.header(); err != nil {
+		return nil, err
+	}
+	return wav, nil
+}
+
+func (wav *WAVWriter) header() error {
+	h := make([]byte, wavHeaderSize)
+	copy(h[0:], "RIFF")
+	binary.LittleEndian.PutUint32(h[4:], 36+wav.size)
+	copy(h[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16)
+	binary.LittleEndian.PutUint16(h[20:], 1) // PCM
+	binary.LittleEndian.PutUint16(h[22:], 1) // mono
+	binary.LittleEndian.PutUint32(h[24:], AudioSampleRate)
+	binary.LittleEndian.PutUint32(h[28:], AudioSampleRate*2)
+	binary.LittleEndian.PutUint16(h[32:], 2)
+	binary.LittleEndian.PutUint16(h[34:], 16)
+	copy(h[36:], "data")
+	binary.LittleEndian.PutUint32(h[40:], wav.size)
+	_, err := wav.w.Write(h)
+	return err
+}
+
+// Write writes audio samples
+func (wav *WAVWriter) Write(p []byte) (int, error) {
+	n, err := wav.w.Write(p)
+	wav.size += uint32(n)
+	return n, err
+}
+
+// Duration returns duration of written audio
+func (wav *WAVWriter) Duration() time.Duration {
+	return samplesDuration(int64(wav.size / 2))
+}
+
+// Close updates header sizes. It does not close underlying writer.
+func (wav *WAVWriter) Close() error {
+	if _, err := wav.w.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := wav.header(); err != nil {
+		return err
+	}
+	_, err := wav.w.Seek(0, io.SeekEnd)
+	return err
+}
+
+/*
+AudioCapture reads EAGI audio stream, detects voice activity and records
+audio to WAV files on demand. Capture should be created at the session
+start, so events time is relative to the session.
+
+Example:
+
+	capture := goagi.NewAudioCapture(goagi.EAGIAudio(), goagi.VAD{})
+	capture.OnEvent = func(e goagi.VADEvent) { log.Printf("%s at %s", e.Type, e.At) }
+	go capture.Run()
+
+	f, _ := os.Create("/var/spool/qa/step-account.wav")
+	capture.StartRecording(f)
+	agi.GetData("enter-account", 0, 10)
+	capture.StopRecording()
+	f.Close()
+*/
+type AudioCapture struct {
+	// OnEvent is called from Run for every voice activity event
+	OnEvent func(VADEvent)
+
+	audio io.Reader
+	mu    sync.Mutex
+	vad   VAD
+	wav   *WAVWriter
+}
+
+// NewAudioCapture creates capture of audio stream with VAD parameters
+func NewAudioCapture(audio io.Reader, vad VAD) *AudioCapture {
+	vad.Reset()
+	return &AudioCapture{audio: audio, vad: vad}
+}
+
+// Position returns duration of audio read since capture created
+func (c *AudioCapture) Position() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.vad.Position()
+}
+
+// Speaking returns true if caller speaks
+func (c *AudioCapture) Speaking() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.vad.Speaking()
+}
+
+// StartRecording starts writing audio to WAV file. Recording in progress
+// is stopped.
+func (c *AudioCapture) StartRecording(w io.WriteSeeker) error {
+	if _, err := c.StopRecording(); err != nil {
+		return err
+	}
+	wav, err := NewWAVWriter(w)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.wav = wav
+	c.mu.Unlock()
+	return nil
+}
+
+// StopRecording stops recording, updates WAV header and returns recording
+// duration. Does nothing if recording is not started.
+func (c *AudioCapture) StopRecording() (time.Duration, error) {
+	c.mu.Lock()
+	wav := c.wav
+	c.wav = nil
+	c.mu.Unlock()
+	if wav == nil {
+		return 0, nil
+	}
+	return wav.Duration(), wav.Close()
+}
+
+// Run reads audio stream until end of stream or error. Recording in
+// progress is stopped when stream ends. Returns nil at the end of stream.
+func (c *AudioCapture) Run() error {
+	frame := make([]byte, AudioFrameSize)
+	for {
+		if _, err := io.ReadFull(c.audio, frame); err != nil {
+			if _, serr := c.StopRecording(); serr != nil {
+				return serr
+			}
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return err
+		}
+
+		c.mu.Lock()
+		ev := c.vad.Process(frame)
+		var err error
+		if c.wav != nil {
+			_, err = c.wav.Write(frame)
+		}
+		c.mu.Unlock()
+		if err != nil {
+			return err
+		}
+
+		if ev.Type != VADNone && c.OnEvent != nil {
+			c.OnEvent(ev)
+		}
+	}
+}
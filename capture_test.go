@@ -0,0 +1,100 @@
+package goagi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWAVWriter(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "test.wav"))
+	assert.Nil(t, err)
+	defer f.Close()
+
+	wav, err := NewWAVWriter(f)
+	assert.Nil(t, err)
+	audio := testAudio(1000, 100*time.Millisecond)
+	_, err = wav.Write(audio)
+	assert.Nil(t, err)
+	assert.Equal(t, 100*time.Millisecond, wav.Duration())
+	assert.Nil(t, wav.Close())
+
+	data, err := os.ReadFile(f.Name())
+	assert.Nil(t, err)
+	assert.Len(t, data, wavHeaderSize+len(audio))
+	assert.Equal(t, "RIFF", string(data[0:4]))
+	assert.Equal(t, uint32(36+len(audio)), binary.LittleEndian.Uint32(data[4:]))
+	assert.Equal(t, "WAVEfmt ", string(data[8:16]))
+	assert.Equal(t, uint16(1), binary.LittleEndian.Uint16(data[22:]))
+	assert.Equal(t, uint32(8000), binary.LittleEndian.Uint32(data[24:]))
+	assert.Equal(t, uint16(16), binary.LittleEndian.Uint16(data[34:]))
+	assert.Equal(t, "data", string(data[36:40]))
+	assert.Equal(t, uint32(len(audio)), binary.LittleEndian.Uint32(data[40:]))
+	assert.Equal(t, audio, data[wavHeaderSize:])
+}
+
+func TestAudioCapture(t *testing.T) {
+	audio := testAudio(
+		0, 500*time.Millisecond,
+		2000, time.Second,
+		0, time.Second)
+	capture := NewAudioCapture(bytes.NewReader(audio), VAD{})
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "speech.wav"))
+	assert.Nil(t, err)
+	defer f.Close()
+
+	// record speech only
+	var events []VADEvent
+	var recorded time.Duration
+	capture.OnEvent = func(e VADEvent) {
+		events = append(events, e)
+		switch e.Type {
+		case VADSpeechStart:
+			assert.Nil(t, capture.StartRecording(f))
+		case VADSpeechEnd:
+			recorded, err = capture.StopRecording()
+			assert.Nil(t, err)
+		}
+	}
+	assert.Nil(t, capture.Run())
+
+	assert.Len(t, events, 2)
+	assert.Equal(t, VADSpeechStart, events[0].Type)
+	assert.Equal(t, 500*time.Millisecond, events[0].At)
+	assert.Equal(t, VADSpeechEnd, events[1].Type)
+	assert.Equal(t, 1500*time.Millisecond, events[1].At)
+	assert.Equal(t, 2500*time.Millisecond, capture.Position())
+	assert.False(t, capture.Speaking())
+
+	stat, err := f.Stat()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(wavHeaderSize)+int64(recorded/time.Millisecond)*16, stat.Size())
+}
+
+func TestAudioCaptureStopOnEOF(t *testing.T) {
+	audio := testAudio(0, 200*time.Millisecond)
+	capture := NewAudioCapture(bytes.NewReader(audio), VAD{})
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "eof.wav"))
+	assert.Nil(t, err)
+	defer f.Close()
+
+	assert.Nil(t, capture.StartRecording(f))
+	assert.Nil(t, capture.Run())
+
+	data, err := os.ReadFile(f.Name())
+	assert.Nil(t, err)
+	assert.Len(t, data, wavHeaderSize+len(audio))
+	assert.Equal(t, uint32(len(audio)), binary.LittleEndian.Uint32(data[40:]))
+
+	// recording already stopped at the end of stream
+	d, err := capture.StopRecording()
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), d)
+}
@@ -0,0 +1,189 @@
+package goagi
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrPrompt prompt is not found in catalog or catalog is invalid
+var ErrPrompt = newError("Prompt")
+
+/*
+Catalog maps logical prompt IDs to sound files per language.
+When prompt has no file for the session language then base language is
+tried ("fr" for "fr_CA") and then catalog fallback languages in order.
+
+Catalog JSON format:
+
+	{
+	  "fallback": ["en"],
+	  "prompts": {
+	    "welcome": {"en": "custom/welcome", "fr": "custom/fr/bienvenue"},
+	    "goodbye": {"en": "vm-goodbye"}
+	  }
+	}
+*/
+type Catalog struct {
+	Fallback  []string                     `json:"fallback"`
+	Prompts   map[string]map[string]string `json:"prompts"`
+	Templates map[string]map[string]string `json:"templates"`
+	plurals   map[string]PluralRule
+	sayRules  map[string]*SayRules
+}
+
+// NewCatalog creates empty catalog with fallback languages chain
+func NewCatalog(fallback ...string) *Catalog {
+	return &Catalog{
+		Fallback: fallback,
+		Prompts:  make(map[string]map[string]string),
+	}
+}
+
+// ParseCatalog parses catalog JSON
+func ParseCatalog(data []byte) (*Catalog, error) {
+	c := NewCatalog()
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, ErrPrompt.Msg("invalid catalog: %s", err)
+	}
+	if c.Prompts == nil {
+		c.Prompts = make(map[string]map[string]string)
+	}
+	return c, nil
+}
+
+// LoadCatalog reads and parses catalog JSON file from file system
+func LoadCatalog(fsys fs.FS, name string) (*Catalog, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCatalog(data)
+}
+
+// LoadCatalogFile reads and parses catalog JSON file by path
+func LoadCatalogFile(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCatalog(data)
+}
+
+// Add sets sound file of the prompt for the language
+func (c *Catalog) Add(id, lang, file string) *Catalog {
+	if c.Prompts == nil {
+		c.Prompts = make(map[string]map[string]string)
+	}
+	if c.Prompts[id] == nil {
+		c.Prompts[id] = make(map[string]string)
+	}
+	c.Prompts[id][lang] = file
+	return c
+}
+
+// Languages returns languages chain that is used to look up prompt
+// for the language
+func (c *Catalog) Languages(lang string) []string {
+	chain := make([]string, 0, len(c.Fallback)+2)
+	add := func(l string) {
+		if l == "" {
+			return
+		}
+		for _, v := range chain {
+			if v == l {
+				return
+			}
+		}
+		chain = append(chain, l)
+	}
+	add(lang)
+	if idx := strings.IndexAny(lang, "_-"); idx > 0 {
+		add(lang[:idx])
+	}
+	for _, l := range c.Fallback {
+		add(l)
+	}
+	return chain
+}
+
+// Lookup returns sound file of the prompt for the language using
+// fallback chain. Returns ErrPrompt if prompt is not found.
+func (c *Catalog) Lookup(id, lang string) (string, error) {
+	files, ok := c.Prompts[id]
+	if !ok {
+		return "", ErrPrompt.Msg("unknown prompt %q", id)
+	}
+	if file, _, ok := c.lookup(files, lang); ok {
+		return file, nil
+	}
+	return "", ErrPrompt.Msg("prompt %q has no file for language %q", id, lang)
+}
+
+// lookup returns value for the language using fallback chain and
+// the language of the found value
+func (c *Catalog) lookup(values map[string]string, lang string) (string, string, bool) {
+	for _, l := range c.Languages(lang) {
+		if val, ok := values[l]; ok && val != "" {
+			return val, l, true
+		}
+	}
+	return "", "", false
+}
+
+// WithCatalog sets prompt catalog used by prompt commands
+func WithCatalog(c *Catalog) Option {
+	return func(agi *AGI) { agi.catalog = c }
+}
+
+// Language returns session language from agi_language environment
+// variable or language set with SetLanguage
+func (agi *AGI) Language() string {
+	if agi.lang != "" {
+		return agi.lang
+	}
+	return agi.Env("language")
+}
+
+// SetLanguage overrides session language used to look up prompts.
+// It does not change channel language in Asterisk.
+func (agi *AGI) SetLanguage(lang string) {
+	agi.lang = lang
+}
+
+// Prompt returns sound file of the prompt for the session language
+func (agi *AGI) Prompt(id string) (string, error) {
+	if agi.catalog == nil {
+		return "", ErrPrompt.Msg("catalog is not set")
+	}
+	return agi.catalog.Lookup(id, agi.Language())
+}
+
+// StreamPrompt is StreamFile with prompt ID from catalog
+func (agi *AGI) StreamPrompt(id, escDigits string, offset int) (Response, error) {
+	file, err := agi.Prompt(id)
+	if err != nil {
+		return nil, err
+	}
+	return agi.StreamFile(file, escDigits, offset)
+}
+
+// GetDataPrompt is GetDataTimeout with prompt ID from catalog
+func (agi *AGI) GetDataPrompt(id string, timeout time.Duration, maxdigit int) (Response, error) {
+	file, err := agi.Prompt(id)
+	if err != nil {
+		return nil, err
+	}
+	return agi.GetDataTimeout(file, timeout, maxdigit)
+}
+
+// GetOptionPrompt is GetOptionTimeout with prompt ID from catalog
+func (agi *AGI) GetOptionPrompt(id, digits string, timeout time.Duration) (Response, error) {
+	file, err := agi.Prompt(id)
+	if err != nil {
+		return nil, err
+	}
+	return agi.GetOptionTimeout(file, digits, timeout)
+}
@@ -0,0 +1,162 @@
+package goagi
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const catalogJSON = `{
+  "fallback": ["en"],
+  "prompts": {
+    "welcome": {"en": "custom/welcome", "fr": "custom/fr/bienvenue", "fr_CA": "custom/qc/allo"},
+    "goodbye": {"en": "vm-goodbye"},
+    "menu": {"ru": "custom/ru/menu"}
+  }
+}`
+
+func TestCatalogLookup(t *testing.T) {
+	c, err := ParseCatalog([]byte(catalogJSON))
+	assert.Nil(t, err)
+
+	tests := []struct {
+		id, lang, file string
+	}{
+		{"welcome", "en", "custom/welcome"},
+		{"welcome", "fr", "custom/fr/bienvenue"},
+		{"welcome", "fr_CA", "custom/qc/allo"},
+		{"welcome", "fr_BE", "custom/fr/bienvenue"},
+		{"welcome", "fr-CH", "custom/fr/bienvenue"},
+		{"welcome", "de", "custom/welcome"},
+		{"welcome", "", "custom/welcome"},
+		{"goodbye", "fr_CA", "vm-goodbye"},
+		{"menu", "ru", "custom/ru/menu"},
+	}
+	for _, tc := range tests {
+		file, err := c.Lookup(tc.id, tc.lang)
+		assert.Nil(t, err, tc)
+		assert.Equal(t, tc.file, file, tc)
+	}
+
+	_, err = c.Lookup("menu", "en")
+	assert.True(t, errors.Is(err, ErrPrompt))
+	assert.Contains(t, err.Error(), `prompt "menu" has no file for language "en"`)
+
+	_, err = c.Lookup("foo", "en")
+	assert.True(t, errors.Is(err, ErrPrompt))
+	assert.Contains(t, err.Error(), `unknown prompt "foo"`)
+}
+
+func TestCatalogLanguages(t *testing.T) {
+	c := NewCatalog("en", "fr")
+	assert.Equal(t, []string{"fr_CA", "fr", "en"}, c.Languages("fr_CA"))
+	assert.Equal(t, []string{"en", "fr"}, c.Languages("en"))
+	assert.Equal(t, []string{"en", "fr"}, c.Languages(""))
+	assert.Equal(t, []string{"pt-BR", "pt", "en", "fr"}, c.Languages("pt-BR"))
+}
+
+func TestCatalogAdd(t *testing.T) {
+	c := NewCatalog("en").Add("hello", "en", "hello-world").Add("hello", "ru", "ru/privet")
+	file, err := c.Lookup("hello", "ru")
+	assert.Nil(t, err)
+	assert.Equal(t, "ru/privet", file)
+
+	c = &Catalog{}
+	c.Add("hello", "en", "hello-world")
+	file, err = c.Lookup("hello", "en")
+	assert.Nil(t, err)
+	assert.Equal(t, "hello-world", file)
+}
+
+func TestCatalogLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"prompts.json": &fstest.MapFile{Data: []byte(catalogJSON)},
+		"broken.json":  &fstest.MapFile{Data: []byte(`{"prompts": [`)},
+		"empty.json":   &fstest.MapFile{Data: []byte(`{}`)},
+	}
+	c, err := LoadCatalog(fsys, "prompts.json")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"en"}, c.Fallback)
+	assert.Equal(t, 3, len(c.Prompts))
+
+	_, err = LoadCatalog(fsys, "broken.json")
+	assert.True(t, errors.Is(err, ErrPrompt))
+	assert.Contains(t, err.Error(), "invalid catalog")
+
+	_, err = LoadCatalog(fsys, "missing.json")
+	assert.NotNil(t, err)
+
+	c, err = LoadCatalog(fsys, "empty.json")
+	assert.Nil(t, err)
+	assert.NotNil(t, c.Prompts)
+
+	path := filepath.Join(t.TempDir(), "prompts.json")
+	assert.Nil(t, os.WriteFile(path, []byte(catalogJSON), 0o600))
+	c, err = LoadCatalogFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(c.Prompts))
+
+	_, err = LoadCatalogFile(path + ".missing")
+	assert.NotNil(t, err)
+}
+
+func TestAGIPrompt(t *testing.T) {
+	c, _ := ParseCatalog([]byte(catalogJSON))
+
+	agi, buf := mockAGI(respOk)
+	_, err := agi.StreamPrompt("welcome", "", 0)
+	assert.True(t, errors.Is(err, ErrPrompt))
+	assert.Contains(t, err.Error(), "catalog is not set")
+
+	agi.catalog = c
+	agi.env = map[string]string{"language": "fr_CA"}
+	assert.Equal(t, "fr_CA", agi.Language())
+	resp, err := agi.StreamPrompt("welcome", "#", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, resp.Result())
+	assert.Equal(t, "STREAM FILE custom/qc/allo \"#\" 0\n", buf.String())
+
+	agi, buf = mockAGI("200 result=12")
+	WithCatalog(c)(agi)
+	agi.SetLanguage("fr")
+	assert.Equal(t, "fr", agi.Language())
+	resp, err = agi.GetDataPrompt("welcome", 2*time.Second, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, "12", resp.Data())
+	assert.Equal(t, "GET DATA custom/fr/bienvenue 2000 3\n", buf.String())
+
+	agi, buf = mockAGI("200 result=49 endpos=100")
+	agi.catalog = c
+	resp, err = agi.GetOptionPrompt("goodbye", "1", time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, 49, resp.Result())
+	assert.Equal(t, "GET OPTION vm-goodbye \"1\" 1000\n", buf.String())
+
+	agi, buf = mockAGI(respOk)
+	agi.catalog = c
+	_, err = agi.GetDataPrompt("menu", 0, 1)
+	assert.True(t, errors.Is(err, ErrPrompt))
+	_, err = agi.GetOptionPrompt("menu", "", 0)
+	assert.True(t, errors.Is(err, ErrPrompt))
+	assert.Empty(t, buf.String())
+}
+
+func TestPlaylistPrompt(t *testing.T) {
+	c, _ := ParseCatalog([]byte(catalogJSON))
+	agi, buf := mockAGIScript("200 result=0 endpos=10", "200 result=0 endpos=10")
+	agi.catalog = c
+	agi.SetLanguage("fr")
+	res, err := NewPlaylist("#").Prompt("welcome").Prompt("goodbye").Play(agi)
+	assert.Nil(t, err)
+	assert.Equal(t, StopFinished, res.Reason)
+	assert.Equal(t, "STREAM FILE custom/fr/bienvenue \"#\" 0\n"+
+		"STREAM FILE vm-goodbye \"#\" 0\n", buf.String())
+
+	_, err = NewPlaylist("").Prompt("menu").Play(agi)
+	assert.True(t, errors.Is(err, ErrPrompt))
+}
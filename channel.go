@@ -0,0 +1,145 @@
+package goagi
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Channel technologies
+const (
+	TechSIP     = "SIP"
+	TechPJSIP   = "PJSIP"
+	TechIAX2    = "IAX2"
+	TechDAHDI   = "DAHDI"
+	TechLocal   = "Local"
+	TechMessage = "Message"
+)
+
+/*
+Channel is parsed channel name "Tech/Resource-Sequence" with optional
+";1" or ";2" leg suffix of Local channels:
+
+	SIP/2222@default-00000023
+	PJSIP/trunk-0000001a
+	IAX2/peer-11773
+	DAHDI/i1/5551234-5
+	Local/100@default-00000002;1
+	Message/ast_msg_queue
+
+Sequence is exactly 8 hex digits, except IAX2 and DAHDI channels that use
+shorter call numbers, so dashed endpoints like "PJSIP/office-a" are parsed
+as resource without sequence. Channel without sequence, like "PJSIP/100",
+is a dial target.
+*/
+type Channel struct {
+	// Tech is channel technology, like "PJSIP"
+	Tech string
+	// Resource is endpoint, peer or dialed resource
+	Resource string
+	// Sequence is unique suffix of channel name
+	Sequence string
+	// Leg of Local channel: 1, 2 or 0 for other channels
+	Leg int
+}
+
+// ParseChannel parses channel name
+func ParseChannel(name string) (Channel, error) {
+	var ch Channel
+	idx := strings.IndexByte(name, '/')
+	if idx < 1 || idx == len(name)-1 {
+		return ch, ErrChannel.Msg("invalid channel name %q", name)
+	}
+	ch.Tech, ch.Resource = name[:idx], name[idx+1:]
+
+	if strings.EqualFold(ch.Tech, TechLocal) {
+		if semi := strings.LastIndexByte(ch.Resource, ';'); semi >= 0 {
+			leg, err := strconv.Atoi(ch.Resource[semi+1:])
+			if err != nil || (leg != 1 && leg != 2) {
+				return ch, ErrChannel.Msg("invalid Local channel leg %q", name)
+			}
+			ch.Leg = leg
+			ch.Resource = ch.Resource[:semi]
+		}
+	}
+
+	if dash := strings.LastIndexByte(ch.Resource, '-'); dash > 0 && ch.isSequence(ch.Resource[dash+1:]) {
+		ch.Sequence = ch.Resource[dash+1:]
+		ch.Resource = ch.Resource[:dash]
+	}
+	if ch.Resource == "" {
+		return ch, ErrChannel.Msg("invalid channel name %q", name)
+	}
+	return ch, nil
+}
+
+// isSequence returns true if s is channel name sequence of the technology
+func (ch Channel) isSequence(s string) bool {
+	switch {
+	case strings.EqualFold(ch.Tech, TechMessage):
+		return false
+	case strings.EqualFold(ch.Tech, TechIAX2), strings.EqualFold(ch.Tech, TechDAHDI):
+		return isHex(s)
+	}
+	return len(s) == 8 && isHex(s)
+}
+
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats channel name
+func (ch Channel) String() string {
+	name := ch.Tech + "/" + ch.Resource
+	if ch.Sequence != "" {
+		name += "-" + ch.Sequence
+	}
+	if ch.Leg > 0 {
+		name += ";" + strconv.Itoa(ch.Leg)
+	}
+	return name
+}
+
+// IsLocal returns true for Local channel
+func (ch Channel) IsLocal() bool { return strings.EqualFold(ch.Tech, TechLocal) }
+
+// Counterpart returns other leg of Local channel: ";2" for ";1" and
+// ";1" for ";2". Other channels are returned unchanged.
+func (ch Channel) Counterpart() Channel {
+	if ch.Leg > 0 {
+		ch.Leg = 3 - ch.Leg
+	}
+	return ch
+}
+
+// Channel returns channel of the AGI session parsed from environment
+func (agi *AGI) Channel() (Channel, error) {
+	return ParseChannel(agi.Env("channel"))
+}
+
+// HangupChannel hangs up channel. See Hangup.
+func (agi *AGI) HangupChannel(ch Channel) (Response, error) {
+	return agi.Hangup(ch.String())
+}
+
+// ChannelStatusOf returns status of channel. See ChannelStatus.
+func (agi *AGI) ChannelStatusOf(ch Channel) (Response, error) {
+	return agi.ChannelStatus(ch.String())
+}
+
+// GetChannelStateOf returns typed state of channel. See GetChannelState.
+func (agi *AGI) GetChannelStateOf(ch Channel) (ChannelState, error) {
+	return agi.GetChannelState(ch.String())
+}
+
+// GetFullVariableOf evaluates expression on channel. See GetFullVariable.
+func (agi *AGI) GetFullVariableOf(name string, ch Channel) (Response, error) {
+	return agi.GetFullVariable(name, ch.String())
+}
@@ -0,0 +1,94 @@
+package goagi
+
+import "strconv"
+
+// ErrChannel channel related error
+var ErrChannel = newError("Channel")
+
+// ChannelState is a state of the channel returned by CHANNEL STATUS command
+type ChannelState int
+
+// Channel states reported by CHANNEL STATUS command
+const (
+	// StateUnknown is returned with error when state can not be detected
+	StateUnknown ChannelState = iota - 1
+	// StateDownAvailable channel is down and available
+	StateDownAvailable
+	// StateDownReserved channel is down, but reserved
+	StateDownReserved
+	// StateOffHook channel is off hook
+	StateOffHook
+	// StateDialing digits (or equivalent) have been dialed
+	StateDialing
+	// StateRinging line is ringing
+	StateRinging
+	// StateRemoteRinging remote end is ringing
+	StateRemoteRinging
+	// StateUp line is up
+	StateUp
+	// StateBusy line is busy
+	StateBusy
+)
+
+var channelStateNames = [...]string{
+	"Down (available)",
+	"Down (reserved)",
+	"Off hook",
+	"Dialing",
+	"Ringing",
+	"Remote ringing",
+	"Up",
+	"Busy",
+}
+
+// String returns human readable channel state
+func (s ChannelState) String() string {
+	if s < StateDownAvailable || s > StateBusy {
+		return "Unknown(" + strconv.Itoa(int(s)) + ")"
+	}
+	return channelStateNames[s]
+}
+
+// IsDown returns true if channel is down, available or reserved
+func (s ChannelState) IsDown() bool {
+	return s == StateDownAvailable || s == StateDownReserved
+}
+
+// IsUp returns true if line is up
+func (s ChannelState) IsUp() bool { return s == StateUp }
+
+// IsRinging returns true if line or remote end is ringing
+func (s ChannelState) IsRinging() bool {
+	return s == StateRinging || s == StateRemoteRinging
+}
+
+// IsBusy returns true if line is busy
+func (s ChannelState) IsBusy() bool { return s == StateBusy }
+
+/*
+GetChannelState returns typed state of the channel. When channel name is empty
+then state of the current channel is returned. Other channels can be queried
+by name.
+
+Returns ErrChannel when Asterisk reports that channel does not exist
+(result=-1) or command fails.
+*/
+func (agi *AGI) GetChannelState(channel string) (ChannelState, error) {
+	resp, err := agi.ChannelStatus(channel)
+	if err != nil {
+		return StateUnknown, err
+	}
+	if resp.Code() != codeSucc {
+		return StateUnknown, ErrChannel.Msg("status failed for %q: %d %s",
+			channel, resp.Code(), resp.Data())
+	}
+	if resp.Result() == -1 {
+		return StateUnknown, ErrChannel.Msg("channel %q does not exist", channel)
+	}
+	state := ChannelState(resp.Result())
+	if state < StateDownAvailable || state > StateBusy {
+		return StateUnknown, ErrChannel.Msg("unknown state %d of channel %q",
+			resp.Result(), channel)
+	}
+	return state, nil
+}
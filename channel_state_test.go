@@ -0,0 +1,68 @@
+package goagi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannelStateString(t *testing.T) {
+	assert.Equal(t, "Down (available)", StateDownAvailable.String())
+	assert.Equal(t, "Ringing", StateRinging.String())
+	assert.Equal(t, "Up", StateUp.String())
+	assert.Equal(t, "Busy", StateBusy.String())
+	assert.Equal(t, "Unknown(-1)", StateUnknown.String())
+	assert.Equal(t, "Unknown(12)", ChannelState(12).String())
+}
+
+func TestChannelStateHelpers(t *testing.T) {
+	assert.True(t, StateDownAvailable.IsDown())
+	assert.True(t, StateDownReserved.IsDown())
+	assert.False(t, StateUp.IsDown())
+	assert.True(t, StateUp.IsUp())
+	assert.False(t, StateBusy.IsUp())
+	assert.True(t, StateRinging.IsRinging())
+	assert.True(t, StateRemoteRinging.IsRinging())
+	assert.False(t, StateDialing.IsRinging())
+	assert.True(t, StateBusy.IsBusy())
+	assert.False(t, StateOffHook.IsBusy())
+}
+
+func TestGetChannelState(t *testing.T) {
+	agi, buf := mockAGI("200 result=6")
+	state, err := agi.GetChannelState("")
+	assert.Nil(t, err)
+	assert.Equal(t, StateUp, state)
+	assert.Equal(t, "CHANNEL STATUS \n", buf.String())
+
+	agi, buf = mockAGI("200 result=4")
+	state, err = agi.GetChannelState("PJSIP/100-00000001")
+	assert.Nil(t, err)
+	assert.True(t, state.IsRinging())
+	assert.Equal(t, "CHANNEL STATUS PJSIP/100-00000001\n", buf.String())
+}
+
+func TestGetChannelStateFail(t *testing.T) {
+	tests := []struct {
+		response string
+		errmsg   string
+	}{
+		{"200 result=-1", `channel "SIP/foo" does not exist`},
+		{"200 result=9", `unknown state 9 of channel "SIP/foo"`},
+		{"511 Command Not Permitted", "status failed for \"SIP/foo\": 511"},
+	}
+	for _, tc := range tests {
+		agi, _ := mockAGI(tc.response)
+		state, err := agi.GetChannelState("SIP/foo")
+		assert.Equal(t, StateUnknown, state)
+		assert.NotNil(t, err)
+		assert.True(t, errors.Is(err, ErrChannel))
+		assert.Contains(t, err.Error(), tc.errmsg)
+	}
+
+	agi, _ := mockAGI("foo")
+	state, err := agi.GetChannelState("")
+	assert.Equal(t, StateUnknown, state)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,93 @@
+package goagi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseChannel(t *testing.T) {
+	tests := []struct {
+		name string
+		want Channel
+	}{
+		{"SIP/2222@default-00000023", Channel{TechSIP, "2222@default", "00000023", 0}},
+		{"PJSIP/trunk-0000001a", Channel{TechPJSIP, "trunk", "0000001a", 0}},
+		{"PJSIP/my-trunk-0000001a", Channel{TechPJSIP, "my-trunk", "0000001a", 0}},
+		{"IAX2/peer-11773", Channel{TechIAX2, "peer", "11773", 0}},
+		{"DAHDI/1-1", Channel{TechDAHDI, "1", "1", 0}},
+		{"DAHDI/i1/5551234-5", Channel{TechDAHDI, "i1/5551234", "5", 0}},
+		{"Local/100@default-00000002;1", Channel{TechLocal, "100@default", "00000002", 1}},
+		{"Local/100@default-00000002;2", Channel{TechLocal, "100@default", "00000002", 2}},
+		{"Message/ast_msg_queue", Channel{TechMessage, "ast_msg_queue", "", 0}},
+		{"PJSIP/100", Channel{TechPJSIP, "100", "", 0}},
+		{"PJSIP/my-trunk", Channel{TechPJSIP, "my-trunk", "", 0}},
+		{"PJSIP/trunk-1", Channel{TechPJSIP, "trunk-1", "", 0}},
+		{"PJSIP/office-a", Channel{TechPJSIP, "office-a", "", 0}},
+		{"SIP/cafe-beef", Channel{TechSIP, "cafe-beef", "", 0}},
+		{"PJSIP/cafe-beef-0000001a", Channel{TechPJSIP, "cafe-beef", "0000001a", 0}},
+		{"Local/100@ctx-2;1", Channel{TechLocal, "100@ctx-2", "", 1}},
+		{"Message/queue-00000001", Channel{TechMessage, "queue-00000001", "", 0}},
+	}
+	for _, tc := range tests {
+		ch, err := ParseChannel(tc.name)
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.want, ch, tc.name)
+		assert.Equal(t, tc.name, ch.String())
+	}
+
+	for _, name := range []string{"", "PJSIP", "PJSIP/", "/100", "Local/100@ctx-00000001;3", "Local/100@ctx-00000001;x"} {
+		_, err := ParseChannel(name)
+		assert.True(t, errors.Is(err, ErrChannel), name)
+	}
+}
+
+func TestChannelLocal(t *testing.T) {
+	ch, err := ParseChannel("Local/100@default-00000002;1")
+	assert.Nil(t, err)
+	assert.True(t, ch.IsLocal())
+	assert.Equal(t, "Local/100@default-00000002;2", ch.Counterpart().String())
+	assert.Equal(t, "Local/100@default-00000002;1", ch.Counterpart().Counterpart().String())
+
+	ch, _ = ParseChannel("PJSIP/trunk-0000001a")
+	assert.False(t, ch.IsLocal())
+	assert.Equal(t, ch, ch.Counterpart())
+}
+
+func TestAGIChannel(t *testing.T) {
+	agi := &AGI{env: map[string]string{"channel": "PJSIP/trunk-0000001a"}}
+	ch, err := agi.Channel()
+	assert.Nil(t, err)
+	assert.Equal(t, Channel{TechPJSIP, "trunk", "0000001a", 0}, ch)
+
+	agi = &AGI{env: map[string]string{}}
+	_, err = agi.Channel()
+	assert.True(t, errors.Is(err, ErrChannel))
+}
+
+func TestChannelCommands(t *testing.T) {
+	ch := Channel{TechPJSIP, "100", "00000001", 0}
+
+	agi, buf := mockAGI("200 result=1")
+	_, err := agi.HangupChannel(ch)
+	assert.Nil(t, err)
+	assert.Equal(t, "HANGUP PJSIP/100-00000001\n", buf.String())
+
+	agi, buf = mockAGI("200 result=6")
+	_, err = agi.ChannelStatusOf(ch)
+	assert.Nil(t, err)
+	assert.Equal(t, "CHANNEL STATUS PJSIP/100-00000001\n", buf.String())
+
+	agi, buf = mockAGI("200 result=6")
+	state, err := agi.GetChannelStateOf(ch)
+	assert.Nil(t, err)
+	assert.Equal(t, StateUp, state)
+	assert.Equal(t, "CHANNEL STATUS PJSIP/100-00000001\n", buf.String())
+
+	agi, buf = mockAGI("200 result=1 (bob)")
+	resp, err := agi.GetFullVariableOf("${CALLERID(name)}", ch)
+	assert.Nil(t, err)
+	assert.Equal(t, "bob", resp.Value())
+	assert.Equal(t, "GET FULL VARIABLE ${CALLERID(name)} PJSIP/100-00000001\n", buf.String())
+}
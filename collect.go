@@ -0,0 +1,205 @@
+package goagi
+
+import (
+	"fmt"
+	"time"
+)
+
+// Default timeouts of Collect
+const (
+	DefaultFirstDigitTimeout = 5 * time.Second
+	DefaultInterDigitTimeout = 3 * time.Second
+)
+
+// CollectStatus is a status of DTMF input collected with Collect
+type CollectStatus int
+
+// Statuses of collected DTMF input
+const (
+	// CollectComplete input is complete: terminated, maximum length reached
+	// or inter-digit timeout after minimum length
+	CollectComplete CollectStatus = iota
+	// CollectTimeout caller has not entered minimum number of digits in time
+	CollectTimeout
+	// CollectCancelled caller pressed cancel key
+	CollectCancelled
+	// CollectTooShort caller pressed terminator before minimum length
+	CollectTooShort
+	// CollectHangup channel hung up
+	CollectHangup
+)
+
+// String returns collect status name
+func (s CollectStatus) String() string {
+	switch s {
+	case CollectComplete:
+		return "complete"
+	case CollectTimeout:
+		return "timeout"
+	case CollectCancelled:
+		return "cancelled"
+	case CollectTooShort:
+		return "too short"
+	case CollectHangup:
+		return "hangup"
+	}
+	return fmt.Sprintf("unknown(%d)", int(s))
+}
+
+// CollectOptions options of DTMF input collector
+type CollectOptions struct {
+	// Prompt is sound file played before collecting. It is interrupted
+	// by any digit and the digit is a part of input
+	Prompt string
+	// FirstTimeout time to wait for first digit after prompt.
+	// DefaultFirstDigitTimeout if zero
+	FirstTimeout time.Duration
+	// InterTimeout time to wait for next digit.
+	// DefaultInterDigitTimeout if zero
+	InterTimeout time.Duration
+	// MinDigits minimum length of the input
+	MinDigits int
+	// MaxDigits maximum length of the input. Zero for no limit
+	MaxDigits int
+	// Terminator digits that complete input, for example "#"
+	Terminator Digits
+	// Cancel digits that cancel input, for example "*"
+	Cancel Digits
+}
+
+// CollectResult is a result of Collect
+type CollectResult struct {
+	// Status of the input
+	Status CollectStatus
+	// Digits entered by caller without terminator or cancel digits
+	Digits Digits
+	// Key is terminator or cancel digit pressed by caller
+	Key string
+}
+
+func (o CollectOptions) validate() error {
+	if err := o.Terminator.Validate(); err != nil {
+		return err
+	}
+	if err := o.Cancel.Validate(); err != nil {
+		return err
+	}
+	if o.Terminator.Intersect(o.Cancel) != NoDigits {
+		return ErrArgument.Msg("terminator and cancel digits overlap")
+	}
+	if o.MinDigits < 0 || o.MaxDigits < 0 {
+		return ErrArgument.Msg("negative number of digits")
+	}
+	if o.MaxDigits > 0 && o.MinDigits > o.MaxDigits {
+		return ErrArgument.Msg("min digits %d is greater than max %d", o.MinDigits, o.MaxDigits)
+	}
+	return nil
+}
+
+func (o CollectOptions) timeout(first bool) time.Duration {
+	if first {
+		if o.FirstTimeout == 0 {
+			return DefaultFirstDigitTimeout
+		}
+		return o.FirstTimeout
+	}
+	if o.InterTimeout == 0 {
+		return DefaultInterDigitTimeout
+	}
+	return o.InterTimeout
+}
+
+/*
+Collect plays optional prompt and gathers DTMF digits with WaitForDigit.
+
+Input is complete when terminator is pressed, maximum number of digits is
+entered or inter-digit timeout expires after minimum number of digits.
+Cancel digits stop input with CollectCancelled status.
+
+Example:
+
+	res, err := agi.Collect(goagi.CollectOptions{
+		Prompt:     "enter-account-number",
+		MinDigits:  4,
+		MaxDigits:  10,
+		Terminator: "#",
+		Cancel:     "*",
+	})
+	if err == nil && res.Status == goagi.CollectComplete {
+		account := res.Digits
+	}
+*/
+func (agi *AGI) Collect(opts CollectOptions) (*CollectResult, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	res := &CollectResult{}
+
+	if opts.Prompt != "" {
+		resp, err := agi.StreamFile(opts.Prompt,
+			string(AllDigits.Union(opts.Terminator).Union(opts.Cancel)), 0)
+		if done, err := res.next(agi, resp, err, opts, true); done || err != nil {
+			return res, err
+		}
+	}
+
+	for {
+		resp, err := agi.WaitForDigitTimeout(opts.timeout(len(res.Digits) == 0))
+		if done, err := res.next(agi, resp, err, opts, false); done || err != nil {
+			return res, err
+		}
+	}
+}
+
+// next handles response of the prompt playback or the command that waits
+// for DTMF and returns true when input is done
+func (res *CollectResult) next(agi *AGI, resp Response, err error,
+	opts CollectOptions, prompt bool,
+) (bool, error) {
+	if err != nil {
+		return true, err
+	}
+	if resp.Code() == codeE511 || resp.Result() < 0 || agi.IsHungup() {
+		res.Status = CollectHangup
+		return true, nil
+	}
+	if resp.Code() != codeSucc {
+		return true, ErrCommand.Msg("%d %s", resp.Code(), resp.Data())
+	}
+
+	if resp.Result() == 0 {
+		// no digit: prompt finished or timeout
+		if prompt {
+			return false, nil
+		}
+		if len(res.Digits) > 0 && len(res.Digits) >= opts.MinDigits {
+			res.Status = CollectComplete
+		} else {
+			res.Status = CollectTimeout
+		}
+		return true, nil
+	}
+
+	digit := string(rune(resp.Result()))
+	switch {
+	case opts.Cancel.Contains(digit):
+		res.Status = CollectCancelled
+		res.Key = digit
+		return true, nil
+	case opts.Terminator.Contains(digit):
+		res.Key = digit
+		if len(res.Digits) < opts.MinDigits {
+			res.Status = CollectTooShort
+		} else {
+			res.Status = CollectComplete
+		}
+		return true, nil
+	}
+
+	res.Digits += Digits(digit)
+	if opts.MaxDigits > 0 && len(res.Digits) >= opts.MaxDigits {
+		res.Status = CollectComplete
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,137 @@
+package goagi
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectStatusString(t *testing.T) {
+	assert.Equal(t, "complete", CollectComplete.String())
+	assert.Equal(t, "timeout", CollectTimeout.String())
+	assert.Equal(t, "cancelled", CollectCancelled.String())
+	assert.Equal(t, "too short", CollectTooShort.String())
+	assert.Equal(t, "hangup", CollectHangup.String())
+	assert.Equal(t, "unknown(10)", CollectStatus(10).String())
+}
+
+func TestCollect(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      CollectOptions
+		responses []string
+		status    CollectStatus
+		digits    Digits
+		key       string
+		cmds      string
+	}{
+		{
+			"terminated",
+			CollectOptions{Prompt: "enter-account", Terminator: "#", Cancel: "*"},
+			[]string{"200 result=49 endpos=100", "200 result=50", "200 result=35"},
+			CollectComplete, "12", "#",
+			"STREAM FILE enter-account \"0123456789*#\" 0\n" +
+				"WAIT FOR DIGIT 3000\n" +
+				"WAIT FOR DIGIT 3000\n",
+		}, {
+			"prompt finished, first digit timeout",
+			CollectOptions{Prompt: "enter-account", FirstTimeout: time.Second},
+			[]string{"200 result=0 endpos=100", "200 result=0"},
+			CollectTimeout, "", "",
+			"STREAM FILE enter-account \"0123456789*#\" 0\n" +
+				"WAIT FOR DIGIT 1000\n",
+		}, {
+			"max digits",
+			CollectOptions{MaxDigits: 3, InterTimeout: 500 * time.Millisecond},
+			[]string{"200 result=49", "200 result=65", "200 result=51"},
+			CollectComplete, "1A3", "",
+			"WAIT FOR DIGIT 5000\nWAIT FOR DIGIT 500\nWAIT FOR DIGIT 500\n",
+		}, {
+			"inter-digit timeout after min length",
+			CollectOptions{MinDigits: 2},
+			[]string{"200 result=49", "200 result=50", "200 result=0"},
+			CollectComplete, "12", "",
+			"WAIT FOR DIGIT 5000\nWAIT FOR DIGIT 3000\nWAIT FOR DIGIT 3000\n",
+		}, {
+			"inter-digit timeout before min length",
+			CollectOptions{MinDigits: 3},
+			[]string{"200 result=49", "200 result=0"},
+			CollectTimeout, "1", "",
+			"WAIT FOR DIGIT 5000\nWAIT FOR DIGIT 3000\n",
+		}, {
+			"terminator before min length",
+			CollectOptions{MinDigits: 3, Terminator: "#"},
+			[]string{"200 result=49", "200 result=35"},
+			CollectTooShort, "1", "#",
+			"WAIT FOR DIGIT 5000\nWAIT FOR DIGIT 3000\n",
+		}, {
+			"cancelled during prompt",
+			CollectOptions{Prompt: "enter-pin", Cancel: "*"},
+			[]string{"200 result=42 endpos=100"},
+			CollectCancelled, "", "*",
+			"STREAM FILE enter-pin \"0123456789*#\" 0\n",
+		}, {
+			"cancelled during prompt with extended digit",
+			CollectOptions{Prompt: "enter-pin", Terminator: "#", Cancel: "D"},
+			[]string{"200 result=68 endpos=100"},
+			CollectCancelled, "", "D",
+			"STREAM FILE enter-pin \"0123456789*#D\" 0\n",
+		}, {
+			"hangup during prompt",
+			CollectOptions{Prompt: "enter-pin"},
+			[]string{"200 result=-1 endpos=100"},
+			CollectHangup, "", "",
+			"STREAM FILE enter-pin \"0123456789*#\" 0\n",
+		}, {
+			"hangup message",
+			CollectOptions{},
+			[]string{"200 result=49", "HANGUP\n200 result=0"},
+			CollectHangup, "1", "",
+			"WAIT FOR DIGIT 5000\nWAIT FOR DIGIT 3000\n",
+		}, {
+			"dead channel",
+			CollectOptions{FirstTimeout: TimeoutInfinite},
+			[]string{"511 Command Not Permitted on a dead channel or intercept routine"},
+			CollectHangup, "", "",
+			"WAIT FOR DIGIT -1\n",
+		},
+	}
+
+	for _, tc := range tests {
+		agi, buf := mockAGIScript(tc.responses...)
+		res, err := agi.Collect(tc.opts)
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.status, res.Status, tc.name)
+		assert.Equal(t, tc.digits, res.Digits, tc.name)
+		assert.Equal(t, tc.key, res.Key, tc.name)
+		assert.Equal(t, tc.cmds, buf.String(), tc.name)
+	}
+}
+
+func TestCollectFail(t *testing.T) {
+	tests := []CollectOptions{
+		{Terminator: "x"},
+		{Cancel: "1 2"},
+		{Terminator: "#", Cancel: "*#"},
+		{MinDigits: -1},
+		{MinDigits: 5, MaxDigits: 4},
+	}
+	for _, opts := range tests {
+		agi, buf := mockAGIScript(respOk)
+		res, err := agi.Collect(opts)
+		assert.Nil(t, res)
+		assert.True(t, errors.Is(err, ErrArgument), opts)
+		assert.Empty(t, buf.String())
+	}
+
+	agi, _ := mockAGIScript("510 Invalid or unknown command")
+	res, err := agi.Collect(CollectOptions{})
+	assert.NotNil(t, res)
+	assert.True(t, errors.Is(err, ErrCommand))
+
+	agi, _ = mockAGIScript("200 result=49")
+	_, err = agi.Collect(CollectOptions{})
+	assert.NotNil(t, err)
+}
@@ -47,6 +47,8 @@ Return values:
 6 - Line is up.
 
 7 - Line is busy.
+
+Use GetChannelState to get typed ChannelState value.
 */
 func (agi *AGI) ChannelStatus(channel string) (Response, error) {
 	cmd := fmt.Sprintf("CHANNEL STATUS %s\n", channel)
@@ -64,8 +66,13 @@ Example:
 	agi.ControlStreamFile("prompt_en", "19", "3000", "#", "0", "#", "1600")
 	agi.ControlStreamFile("prompt_en", "")
 	agi.ControlStreamFile("prompt_en", "19", "", "", "", "#", "1600")
+
+See ControlStreamFileOpts for named options and typed result.
 */
 func (agi *AGI) ControlStreamFile(filename, digits string, args ...string) (Response, error) {
+	if err := validDigits(digits); err != nil {
+		return nil, err
+	}
 	cmd := fmt.Sprintf("CONTROL STREAM FILE %s %q", filename, digits)
 
 	if len(args) > 5 {
@@ -111,6 +118,9 @@ func (agi *AGI) DatabasePut(family, key, val string) (Response, error) {
 }
 
 // Exec executes application with given options.
+//
+// See Dial, Queue, Playback and other application helpers that build
+// options and read application status.
 func (agi *AGI) Exec(app, opts string) (Response, error) {
 	cmd := fmt.Sprintf("EXEC %s %q\n", app, opts)
 	return agi.execute(cmd)
@@ -124,7 +134,8 @@ Contrary to other responses, where result has numeric value,
 here asterisk puts DTMF to sent by user to result and this value
 may contain "#" and "*".
 
-To get DTMF sent by user use Response.Data()
+To get DTMF sent by user use Response.Data(). Use ParseDigits to
+convert it to Digits.
 
 Response.Value() will contain "timeout" if user has not terminated
 input with "#"
@@ -162,17 +173,26 @@ func (agi *AGI) GetFullVariable(name, channel string) (Response, error) {
 //	Behaves similar to STREAM FILE but used with a timeout option.
 //	Returns digit pressed, offset and error
 func (agi *AGI) GetOption(filename, digits string, timeout int32) (Response, error) {
+	if err := validDigits(digits); err != nil {
+		return nil, err
+	}
 	cmd := fmt.Sprintf("GET OPTION %s %q %d\n", filename, digits, timeout)
 	return agi.execute(cmd)
 }
 
 // GetVariable Gets a channel variable.
+//
+// Response result is 0 when variable is not set. See Variable and GetVar
+// for helpers that distinguish unset variable from empty value.
 func (agi *AGI) GetVariable(name string) (Response, error) {
 	cmd := fmt.Sprintf("GET VARIABLE %s\n", name)
 	return agi.execute(cmd)
 }
 
 // Hangup hangs up the specified channel. If no channel name is given, hangs up the current channel
+//
+// Commands taking channel name have variants accepting parsed Channel,
+// like HangupChannel and ChannelStatusOf.
 func (agi *AGI) Hangup(channel ...string) (Response, error) {
 	cmd := "HANGUP"
 
@@ -225,10 +245,15 @@ silence is the number of seconds of silence that are permitted before the
 recording is terminated, regardless of the escape_digits or timeout arguments
 
 If interrupted by DTMF, digits will be available in Response.Data()
+
+See RecordFileOpts for named options and typed result.
 */
 func (agi *AGI) RecordFile(file, format, escDigits string,
 	timeout, offset int, beep bool, silence int,
 ) (Response, error) {
+	if err := validDigits(escDigits); err != nil {
+		return nil, err
+	}
 	cmd := "RECORD FILE"
 	cmd = fmt.Sprintf("%s %s %s %q %d", cmd, file, format, escDigits, timeout)
 	if offset > 0 {
@@ -261,6 +286,9 @@ func (agi *AGI) RecordFile(file, format, escDigits string,
 // SayAlpha says a given character string, returning early if any of the given
 // DTMF digits are received on the channel.
 func (agi *AGI) SayAlpha(line, escDigits string) (Response, error) {
+	if err := validDigits(escDigits); err != nil {
+		return nil, err
+	}
 	cmd := fmt.Sprintf("SAY ALPHA %s %q\n", line, escDigits)
 	return agi.execute(cmd)
 }
@@ -268,6 +296,9 @@ func (agi *AGI) SayAlpha(line, escDigits string) (Response, error) {
 // SayDate say a given date, returning early if any of the given DTMF digits
 // are received on the channel
 func (agi *AGI) SayDate(date, escDigits string) (Response, error) {
+	if err := validDigits(escDigits); err != nil {
+		return nil, err
+	}
 	cmd := fmt.Sprintf("SAY DATE %s %q\n", date, escDigits)
 	return agi.execute(cmd)
 }
@@ -275,6 +306,9 @@ func (agi *AGI) SayDate(date, escDigits string) (Response, error) {
 // SayDatetime say a given time, returning early if any of the given DTMF
 // digits are received on the channel
 func (agi *AGI) SayDatetime(time, escDigits, format, timezone string) (Response, error) {
+	if err := validDigits(escDigits); err != nil {
+		return nil, err
+	}
 	cmd := fmt.Sprintf("SAY DATETIME %s %q %q %q\n", time, escDigits, format, timezone)
 	return agi.execute(cmd)
 }
@@ -282,6 +316,9 @@ func (agi *AGI) SayDatetime(time, escDigits, format, timezone string) (Response,
 // SayDigits say a given digit string, returning early if any of the given
 // DTMF digits are received on the channel
 func (agi *AGI) SayDigits(number, escDigits string) (Response, error) {
+	if err := validDigits(escDigits); err != nil {
+		return nil, err
+	}
 	cmd := fmt.Sprintf("SAY DIGITS %s %q\n", number, escDigits)
 	return agi.execute(cmd)
 }
@@ -289,13 +326,32 @@ func (agi *AGI) SayDigits(number, escDigits string) (Response, error) {
 // SayNumber say a given digit string, returning early if any of the given
 // DTMF digits are received on the channel
 func (agi *AGI) SayNumber(number, escDigits string) (Response, error) {
+	if err := validDigits(escDigits); err != nil {
+		return nil, err
+	}
 	cmd := fmt.Sprintf("SAY NUMBER %s %q\n", number, escDigits)
 	return agi.execute(cmd)
 }
 
+// SayNumberGender say a given number with gender, for languages where numbers
+// are said differently for genders. For example "f", "m", "n" or "c".
+func (agi *AGI) SayNumberGender(number, escDigits, gender string) (Response, error) {
+	if err := validDigits(escDigits); err != nil {
+		return nil, err
+	}
+	if gender == "" {
+		return agi.SayNumber(number, escDigits)
+	}
+	cmd := fmt.Sprintf("SAY NUMBER %s %q %s\n", number, escDigits, gender)
+	return agi.execute(cmd)
+}
+
 // SayPhonetic say a given character string with phonetics, returning early
 // if any of the given DTMF digits are received on the channel
 func (agi *AGI) SayPhonetic(str, escDigits string) (Response, error) {
+	if err := validDigits(escDigits); err != nil {
+		return nil, err
+	}
 	cmd := fmt.Sprintf("SAY PHONETIC %s %q\n", str, escDigits)
 	return agi.execute(cmd)
 }
@@ -303,6 +359,9 @@ func (agi *AGI) SayPhonetic(str, escDigits string) (Response, error) {
 // SayTime say a given time, returning early if any of the given DTMF digits
 // are received on the channel
 func (agi *AGI) SayTime(time, escDigits string) (Response, error) {
+	if err := validDigits(escDigits); err != nil {
+		return nil, err
+	}
 	cmd := fmt.Sprintf("SAY TIME %s %q\n", time, escDigits)
 	return agi.execute(cmd)
 }
@@ -329,12 +388,14 @@ func (agi *AGI) SetAutoHangup(seconds int) (Response, error) {
 }
 
 // SetCallerid Changes the callerid of the current channel.
+// Use SetCallerID to set typed CallerID with escaping and presentation.
 func (agi *AGI) SetCallerid(clid string) (Response, error) {
 	cmd := fmt.Sprintf("SET CALLERID %q\n", clid)
 	return agi.execute(cmd)
 }
 
 // SetContext Sets the context for continuation upon exiting the application.
+// Use Goto to set context, extension and priority together.
 func (agi *AGI) SetContext(ctx string) (Response, error) {
 	cmd := fmt.Sprintf("SET CONTEXT %s\n", ctx)
 	return agi.execute(cmd)
@@ -377,6 +438,9 @@ func (agi *AGI) SetVariable(name, value string) (Response, error) {
 // StreamFile Send the given file, allowing playback to be interrupted by the given
 // digits, if any.
 func (agi *AGI) StreamFile(file, escDigits string, offset int) (Response, error) {
+	if err := validDigits(escDigits); err != nil {
+		return nil, err
+	}
 	cmd := fmt.Sprintf("STREAM FILE %s %q %d\n", file, escDigits, offset)
 	return agi.execute(cmd)
 }
@@ -2,6 +2,7 @@ package goagi
 
 import (
 	"bytes"
+	"io"
 	"strings"
 	"testing"
 
@@ -22,6 +23,37 @@ func mockAGI(response string) (*AGI, *bytes.Buffer) {
 	return &AGI{reader: r, writer: w}, buf
 }
 
+// scriptReader returns one line per Read call, so responses of the
+// consequent commands are not consumed by buffered reader of the previous one
+type scriptReader struct {
+	lines []string
+}
+
+func (r *scriptReader) Read(b []byte) (int, error) {
+	if len(r.lines) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, r.lines[0])
+	r.lines[0] = r.lines[0][n:]
+	if r.lines[0] == "" {
+		r.lines = r.lines[1:]
+	}
+	return n, nil
+}
+
+// mockAGIScript creates AGI that replies with responses in order,
+// one response per command
+func mockAGIScript(responses ...string) (*AGI, *bytes.Buffer) {
+	buf := new(bytes.Buffer)
+	reader := &scriptReader{}
+	for _, resp := range responses {
+		for _, line := range strings.Split(resp, "\n") {
+			reader.lines = append(reader.lines, line+"\n")
+		}
+	}
+	return &AGI{reader: reader, writer: &stubWriter{buf}}, buf
+}
+
 const respOk = "200 result=1"
 
 func TestCmdCommand(t *testing.T) {
@@ -350,6 +382,19 @@ func TestCmdSayNumber(t *testing.T) {
 	assert.Equal(t, "SAY NUMBER 1000 \"01\"\n", buf.String())
 }
 
+func TestCmdSayNumberGender(t *testing.T) {
+	agi, buf := mockAGI(respOk)
+	resp, err := agi.SayNumberGender("21", "", "f")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, resp.Result())
+	assert.Equal(t, "SAY NUMBER 21 \"\" f\n", buf.String())
+
+	agi, buf = mockAGI(respOk)
+	_, err = agi.SayNumberGender("21", "#", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "SAY NUMBER 21 \"#\"\n", buf.String())
+}
+
 func TestCmdSayPhonetic(t *testing.T) {
 	agi, buf := mockAGI(respOk)
 	resp, err := agi.SayPhonetic("welcome", "")
@@ -0,0 +1,201 @@
+package goagi
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AMDStatus is value of AMDSTATUS variable
+type AMDStatus string
+
+// AMD statuses
+const (
+	AMDHuman   AMDStatus = "HUMAN"
+	AMDMachine AMDStatus = "MACHINE"
+	AMDNotSure AMDStatus = "NOTSURE"
+	AMDHangup  AMDStatus = "HANGUP"
+)
+
+// Fax tones frequencies in Hz
+const (
+	// FaxToneCED is answering fax tone
+	FaxToneCED = 2100
+	// FaxToneCNG is calling fax tone
+	FaxToneCNG = 1100
+)
+
+// Fax detection defaults
+const (
+	DefaultFaxDetectTimeout  = 5 * time.Second
+	DefaultFaxDetectDuration = 500 * time.Millisecond
+	DefaultFaxDetectInterval = 500 * time.Millisecond
+)
+
+/*
+AMDConfig arguments of AMD application. Zero values use amd.conf
+settings.
+*/
+type AMDConfig struct {
+	// InitialSilence is maximum silence before greeting
+	InitialSilence time.Duration
+	// Greeting is maximum length of greeting
+	Greeting time.Duration
+	// AfterGreetingSilence is silence after greeting to detect human
+	AfterGreetingSilence time.Duration
+	// TotalAnalysisTime is maximum time of analysis
+	TotalAnalysisTime time.Duration
+	// MinWordLength is minimum duration of voice considered as word
+	MinWordLength time.Duration
+	// BetweenWordsSilence is minimum silence between words
+	BetweenWordsSilence time.Duration
+	// MaxWords is maximum number of words in greeting
+	MaxWords int
+	// SilenceThreshold is average level of noise considered silence
+	SilenceThreshold int
+	// MaxWordLength is maximum duration of a single word
+	MaxWordLength time.Duration
+}
+
+// AMDResult result of answering machine detection
+type AMDResult struct {
+	Status AMDStatus
+	// Cause of the status, like "TOOLONG", "INITIALSILENCE" or "MAXWORDS"
+	Cause string
+	// Details numbers of AMDCAUSE, like detected and maximum silence
+	Details []int
+}
+
+/*
+AMD runs answering machine detection on answered channel and returns
+result from AMDSTATUS and AMDCAUSE variables.
+
+	res, err := agi.AMD(goagi.AMDConfig{InitialSilence: 2500 * time.Millisecond})
+	if err == nil && res.Status == goagi.AMDMachine {
+		agi.StreamFile("campaign-message", "", 0)
+	}
+*/
+func (agi *AGI) AMD(conf AMDConfig) (*AMDResult, error) {
+	ms := func(d time.Duration) string {
+		if d <= 0 {
+			return ""
+		}
+		return strconv.Itoa(durationMs(d))
+	}
+	num := func(n int) string {
+		if n <= 0 {
+			return ""
+		}
+		return strconv.Itoa(n)
+	}
+	args := appArgs(ms(conf.InitialSilence), ms(conf.Greeting),
+		ms(conf.AfterGreetingSilence), ms(conf.TotalAnalysisTime),
+		ms(conf.MinWordLength), ms(conf.BetweenWordsSilence),
+		num(conf.MaxWords), num(conf.SilenceThreshold), ms(conf.MaxWordLength))
+	if err := agi.execApp("AMD", args); err != nil {
+		return nil, err
+	}
+
+	vars, err := agi.GetVariables("AMDSTATUS", "AMDCAUSE")
+	if err != nil {
+		return nil, err
+	}
+	status, ok := vars["AMDSTATUS"]
+	if !ok {
+		return nil, ErrCommand.Msg("AMD did not set AMDSTATUS")
+	}
+	res := ParseAMDCause(vars["AMDCAUSE"])
+	res.Status = AMDStatus(status)
+	return res, nil
+}
+
+// ParseAMDCause parses AMDCAUSE value like "INITIALSILENCE-2500-2500"
+// to cause and numeric details. Status is not set.
+func ParseAMDCause(cause string) *AMDResult {
+	res := &AMDResult{}
+	parts := strings.Split(cause, "-")
+	res.Cause = parts[0]
+	for _, part := range parts[1:] {
+		if n, err := strconv.Atoi(part); err == nil {
+			res.Details = append(res.Details, n)
+		}
+	}
+	return res
+}
+
+// FaxDetectOptions options of fax tone detection
+type FaxDetectOptions struct {
+	// Tone frequency in Hz, FaxToneCED by default
+	Tone int
+	// Duration is minimum tone duration
+	Duration time.Duration
+	// Timeout to listen for the tone
+	Timeout time.Duration
+	// Interval between detector checks, DefaultFaxDetectInterval if zero
+	Interval time.Duration
+}
+
+// FaxDetectResult result of fax tone detection
+type FaxDetectResult struct {
+	// Detected is true if tone was detected
+	Detected bool
+	// Tone frequency in Hz that was listened for
+	Tone int
+	// Elapsed is time listened until tone was detected or timeout
+	Elapsed time.Duration
+}
+
+/*
+DetectFax listens to the channel for fax tone and returns as soon as tone
+is detected or timeout expires. Detector is checked every Interval.
+Detection uses TONE_DETECT dialplan function available in Asterisk 16.21,
+18.7 and later. Detector is removed from the channel before return.
+
+	res, err := agi.DetectFax(goagi.FaxDetectOptions{Timeout: 4 * time.Second})
+	if err == nil && res.Detected {
+		agi.ReceiveFax("/var/spool/fax/in.tif", goagi.ReceiveFaxOptions{})
+	}
+*/
+func (agi *AGI) DetectFax(opts FaxDetectOptions) (res *FaxDetectResult, err error) {
+	tone := opts.Tone
+	if tone <= 0 {
+		tone = FaxToneCED
+	}
+	duration := orDefaultDuration(opts.Duration, DefaultFaxDetectDuration)
+	timeout := orDefaultDuration(opts.Timeout, DefaultFaxDetectTimeout)
+	interval := orDefaultDuration(opts.Interval, DefaultFaxDetectInterval)
+
+	err = agi.SetFunc("TONE_DETECT", "", strconv.Itoa(tone),
+		strconv.Itoa(durationMs(duration)))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if rerr := agi.SetFunc("TONE_DETECT", ""); rerr != nil {
+			res, err = nil, errors.Join(err, rerr)
+		}
+	}()
+
+	res = &FaxDetectResult{Tone: tone}
+	for res.Elapsed < timeout {
+		step := interval
+		if left := timeout - res.Elapsed; left < step {
+			step = left
+		}
+		if err := agi.execApp("Wait", strconv.FormatFloat(step.Seconds(), 'f', -1, 64)); err != nil {
+			return nil, err
+		}
+		res.Elapsed += step
+
+		val, err := agi.Func("TONE_DETECT", "rx")
+		if err != nil {
+			return nil, err
+		}
+		if count, _ := strconv.Atoi(val); count > 0 {
+			res.Detected = true
+			break
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,123 @@
+package goagi
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAMDCause(t *testing.T) {
+	tests := []struct {
+		cause   string
+		reason  string
+		details []int
+	}{
+		{"INITIALSILENCE-2500-2500", "INITIALSILENCE", []int{2500, 2500}},
+		{"HUMAN-300-300", "HUMAN", []int{300, 300}},
+		{"MAXWORDS-4-4", "MAXWORDS", []int{4, 4}},
+		{"LONGGREETING-1600-1500", "LONGGREETING", []int{1600, 1500}},
+		{"TOOLONG-5000", "TOOLONG", []int{5000}},
+		{"HANGUP", "HANGUP", nil},
+		{"", "", nil},
+	}
+	for _, tc := range tests {
+		res := ParseAMDCause(tc.cause)
+		assert.Equal(t, tc.reason, res.Cause, tc.cause)
+		assert.Equal(t, tc.details, res.Details, tc.cause)
+	}
+}
+
+func TestAMD(t *testing.T) {
+	tests := []struct {
+		status string
+		cause  string
+		want   *AMDResult
+	}{
+		{"HUMAN", "HUMAN-300-300", &AMDResult{AMDHuman, "HUMAN", []int{300, 300}}},
+		{"MACHINE", "MAXWORDS-4-4", &AMDResult{AMDMachine, "MAXWORDS", []int{4, 4}}},
+		{"NOTSURE", "TOOLONG-5000", &AMDResult{AMDNotSure, "TOOLONG", []int{5000}}},
+		{"HANGUP", "HANGUP", &AMDResult{AMDHangup, "HANGUP", nil}},
+	}
+	for _, tc := range tests {
+		agi, buf := mockAGIScript("200 result=0",
+			"200 result=1 ("+tc.status+")", "200 result=1 ("+tc.cause+")")
+		res, err := agi.AMD(AMDConfig{})
+		assert.Nil(t, err)
+		assert.Equal(t, tc.want, res)
+		assert.Equal(t, "EXEC AMD \"\"\nGET VARIABLE AMDSTATUS\nGET VARIABLE AMDCAUSE\n",
+			buf.String())
+	}
+}
+
+func TestAMDConfig(t *testing.T) {
+	agi, buf := mockAGIScript("200 result=0",
+		"200 result=1 (MACHINE)", "200 result=1 (INITIALSILENCE-2500-2500)")
+	_, err := agi.AMD(AMDConfig{
+		InitialSilence:       2500 * time.Millisecond,
+		Greeting:             1500 * time.Millisecond,
+		AfterGreetingSilence: 800 * time.Millisecond,
+		TotalAnalysisTime:    5 * time.Second,
+		MaxWords:             3,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "EXEC AMD \"2500,1500,800,5000,,,3\"\n"+
+		"GET VARIABLE AMDSTATUS\nGET VARIABLE AMDCAUSE\n", buf.String())
+}
+
+func TestAMDFail(t *testing.T) {
+	agi, _ := mockAGI("200 result=-2")
+	_, err := agi.AMD(AMDConfig{})
+	assert.True(t, errors.Is(err, ErrCommand))
+
+	agi, _ = mockAGIScript("200 result=0", "200 result=0", "200 result=0")
+	_, err = agi.AMD(AMDConfig{})
+	assert.True(t, errors.Is(err, ErrCommand))
+
+	agi, _ = mockAGIScript("200 result=0",
+		"511 Command Not Permitted on a dead channel")
+	_, err = agi.AMD(AMDConfig{})
+	assert.True(t, errors.Is(err, ErrCommand))
+}
+
+func TestDetectFax(t *testing.T) {
+	// detected on second check
+	agi, buf := mockAGIScript("200 result=1", "200 result=0", "200 result=1 (0)",
+		"200 result=0", "200 result=1 (2)", "200 result=1")
+	res, err := agi.DetectFax(FaxDetectOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, &FaxDetectResult{true, FaxToneCED, time.Second}, res)
+	assert.Equal(t, "SET VARIABLE \"TONE_DETECT(2100,500)\" \"\"\n"+
+		"EXEC Wait \"0.5\"\n"+
+		"GET FULL VARIABLE \"${TONE_DETECT(rx)}\"\n"+
+		"EXEC Wait \"0.5\"\n"+
+		"GET FULL VARIABLE \"${TONE_DETECT(rx)}\"\n"+
+		"SET VARIABLE \"TONE_DETECT()\" \"\"\n", buf.String())
+
+	// not detected till timeout
+	agi, buf = mockAGIScript("200 result=1", "200 result=0", "200 result=1 (0)",
+		"200 result=0", "200 result=1 (0)", "200 result=1")
+	res, err = agi.DetectFax(FaxDetectOptions{Tone: FaxToneCNG,
+		Duration: time.Second, Timeout: 3500 * time.Millisecond, Interval: 2 * time.Second})
+	assert.Nil(t, err)
+	assert.Equal(t, &FaxDetectResult{false, FaxToneCNG, 3500 * time.Millisecond}, res)
+	assert.Equal(t, "SET VARIABLE \"TONE_DETECT(1100,1000)\" \"\"\n"+
+		"EXEC Wait \"2\"\n"+
+		"GET FULL VARIABLE \"${TONE_DETECT(rx)}\"\n"+
+		"EXEC Wait \"1.5\"\n"+
+		"GET FULL VARIABLE \"${TONE_DETECT(rx)}\"\n"+
+		"SET VARIABLE \"TONE_DETECT()\" \"\"\n", buf.String())
+
+	agi, buf = mockAGI("510 Invalid or unknown command")
+	_, err = agi.DetectFax(FaxDetectOptions{})
+	assert.True(t, errors.Is(err, ErrCommand))
+	assert.NotContains(t, buf.String(), "TONE_DETECT()")
+
+	// detector is removed when waiting fails
+	agi, buf = mockAGIScript("200 result=1", "200 result=-1", "200 result=1")
+	res, err = agi.DetectFax(FaxDetectOptions{})
+	assert.Nil(t, res)
+	assert.NotNil(t, err)
+	assert.Contains(t, buf.String(), "SET VARIABLE \"TONE_DETECT()\" \"\"\n")
+}
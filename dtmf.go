@@ -0,0 +1,104 @@
+package goagi
+
+import "strings"
+
+/*
+Digits is a sequence or a set of DTMF digits: 0-9, *, # and A-D.
+It is used for escape digits of the commands and for DTMF input
+received from the channel, for example with GetData.
+
+Escape digits of all commands are validated with Digits.Validate and
+invalid digits return ErrArgument without sending command to Asterisk.
+AGI command methods keep escDigits string arguments for compatibility,
+helpers like Collect, Playlist, Listen and option structs take Digits.
+Single pressed digit, like MenuEvent.Digit or menu option key, is a string.
+*/
+type Digits string
+
+// Predefined escape digits sets
+const (
+	// NoDigits empty set, playback can not be interrupted
+	NoDigits Digits = ""
+	// NumericDigits digits 0-9
+	NumericDigits Digits = "0123456789"
+	// AllDigits all keys of the phone keypad
+	AllDigits Digits = "0123456789*#"
+)
+
+const dtmfSymbols = "0123456789*#ABCD"
+
+// IsDTMF returns true if rune is a valid DTMF digit
+func IsDTMF(c rune) bool {
+	return strings.ContainsRune(dtmfSymbols, c)
+}
+
+// ParseDigits validates string and returns Digits.
+// Returns ErrArgument if string contains not DTMF symbols.
+func ParseDigits(s string) (Digits, error) {
+	d := Digits(s)
+	if err := d.Validate(); err != nil {
+		return NoDigits, err
+	}
+	return d, nil
+}
+
+// Validate returns ErrArgument if digits contain not DTMF symbols
+func (d Digits) Validate() error {
+	for _, c := range d {
+		if !IsDTMF(c) {
+			return ErrArgument.Msg("invalid DTMF digit %q in %q", c, string(d))
+		}
+	}
+	return nil
+}
+
+// String returns digits as string
+func (d Digits) String() string { return string(d) }
+
+// Contains returns true if digit is in the set
+func (d Digits) Contains(digit string) bool {
+	return len(digit) == 1 && strings.Contains(string(d), digit)
+}
+
+// Union returns set of digits that are in any of the sets
+func (d Digits) Union(other Digits) Digits {
+	return (d + other).set()
+}
+
+// Intersect returns set of digits that are in both sets
+func (d Digits) Intersect(other Digits) Digits {
+	var b strings.Builder
+	for _, c := range d.set() {
+		if strings.ContainsRune(string(other), c) {
+			b.WriteRune(c)
+		}
+	}
+	return Digits(b.String())
+}
+
+// Without returns set of digits that are not in other set
+func (d Digits) Without(other Digits) Digits {
+	var b strings.Builder
+	for _, c := range d.set() {
+		if !strings.ContainsRune(string(other), c) {
+			b.WriteRune(c)
+		}
+	}
+	return Digits(b.String())
+}
+
+// set returns digits without duplicates keeping the order
+func (d Digits) set() Digits {
+	var b strings.Builder
+	for i, c := range d {
+		if !strings.ContainsRune(string(d[:i]), c) {
+			b.WriteRune(c)
+		}
+	}
+	return Digits(b.String())
+}
+
+// validDigits validates escape digits argument of the command
+func validDigits(digits string) error {
+	return Digits(digits).Validate()
+}
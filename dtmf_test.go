@@ -0,0 +1,70 @@
+package goagi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDigits(t *testing.T) {
+	for _, s := range []string{"", "0123456789", "*#", "ABCD", "1#1#"} {
+		d, err := ParseDigits(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, s, d.String())
+	}
+
+	for _, s := range []string{"1 2", "abcd", "E", "12,3", "\n"} {
+		d, err := ParseDigits(s)
+		assert.Equal(t, NoDigits, d)
+		assert.True(t, errors.Is(err, ErrArgument), s)
+	}
+	_, err := ParseDigits("1 2")
+	assert.Contains(t, err.Error(), `invalid DTMF digit ' ' in "1 2"`)
+}
+
+func TestIsDTMF(t *testing.T) {
+	assert.True(t, IsDTMF('0'))
+	assert.True(t, IsDTMF('#'))
+	assert.True(t, IsDTMF('D'))
+	assert.False(t, IsDTMF('d'))
+	assert.False(t, IsDTMF(' '))
+}
+
+func TestDigitsSet(t *testing.T) {
+	assert.True(t, AllDigits.Contains("#"))
+	assert.False(t, AllDigits.Contains("A"))
+	assert.False(t, AllDigits.Contains("12"))
+	assert.False(t, NoDigits.Contains("1"))
+
+	assert.Equal(t, Digits("0123456789*#"), NumericDigits.Union("*#*"))
+	assert.Equal(t, Digits("12"), Digits("1122").Union(NoDigits))
+	assert.Equal(t, Digits("19"), Digits("1#9").Intersect(NumericDigits))
+	assert.Equal(t, NoDigits, Digits("*#").Intersect(NumericDigits))
+	assert.Equal(t, Digits("*#"), AllDigits.Without(NumericDigits))
+	assert.Equal(t, Digits("0123456789"), AllDigits.Without("*#"))
+}
+
+func TestCmdInvalidEscapeDigits(t *testing.T) {
+	calls := []func(agi *AGI) (Response, error){
+		func(agi *AGI) (Response, error) { return agi.ControlStreamFile("f", "1 2") },
+		func(agi *AGI) (Response, error) { return agi.GetOption("f", "1 2", 0) },
+		func(agi *AGI) (Response, error) { return agi.RecordFile("f", "wav", "1 2", 0, 0, false, 0) },
+		func(agi *AGI) (Response, error) { return agi.SayAlpha("abc", "1 2") },
+		func(agi *AGI) (Response, error) { return agi.SayDate("0", "1 2") },
+		func(agi *AGI) (Response, error) { return agi.SayDatetime("0", "1 2", "", "") },
+		func(agi *AGI) (Response, error) { return agi.SayDigits("12", "1 2") },
+		func(agi *AGI) (Response, error) { return agi.SayNumber("12", "1 2") },
+		func(agi *AGI) (Response, error) { return agi.SayPhonetic("abc", "1 2") },
+		func(agi *AGI) (Response, error) { return agi.SayTime("0", "1 2") },
+		func(agi *AGI) (Response, error) { return agi.StreamFile("f", "1 2", 0) },
+		func(agi *AGI) (Response, error) { return agi.GetOptionTimeout("f", "1 2", TimeoutDefault) },
+	}
+	for i, call := range calls {
+		agi, buf := mockAGI(respOk)
+		resp, err := call(agi)
+		assert.Nil(t, resp, i)
+		assert.True(t, errors.Is(err, ErrArgument), i)
+		assert.Empty(t, buf.String(), i)
+	}
+}
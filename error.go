@@ -4,21 +4,32 @@ import "fmt"
 
 // Error object for goagi library
 type Error struct {
-	s string
-	e string
+	s    string
+	e    string
+	base *Error
 }
 
 func newError(ctx string) *Error {
 	return &Error{s: ctx}
 }
 
-// Msg append message to main context message
+// Msg returns new error with message appended to main context message.
+// The error matches its origin with errors.Is.
 func (e *Error) Msg(msg string, args ...interface{}) error {
-	e.e = fmt.Sprintf(msg, args...)
-	return e
+	base := e
+	if e.base != nil {
+		base = e.base
+	}
+	return &Error{s: e.s, e: fmt.Sprintf(msg, args...), base: base}
 }
 
 // Error message for the Error object
 func (e *Error) Error() string {
 	return fmt.Sprintf("%s: %s", e.s, e.e)
 }
+
+// Is returns true if target is the error this error was created from
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && e.base != nil && t == e.base
+}
@@ -1,6 +1,8 @@
 package goagi
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -8,9 +10,10 @@ import (
 
 func TestErrorNew(t *testing.T) {
 	err := newError("Foo")
-	err.Msg("bar")
+	msg := err.Msg("bar")
 	assert.Equal(t, "Foo", err.s)
-	assert.Equal(t, "bar", err.e)
+	assert.Equal(t, "", err.e)
+	assert.Equal(t, "Foo: bar", msg.Error())
 }
 
 func TestErrorMessage(t *testing.T) {
@@ -27,5 +30,16 @@ func TestErrorMessageArgs(t *testing.T) {
 	err := ArgErr.Msg("has invalid value %d", -5)
 
 	assert.Equal(t, "EArg: has invalid value -5", err.Error())
-	assert.Equal(t, ArgErr, err)
+	assert.True(t, errors.Is(err, ArgErr))
+	assert.False(t, errors.Is(err, newError("EArg")))
+}
+
+func TestErrorMsgOfMsg(t *testing.T) {
+	ArgErr := newError("EArg")
+	err := ArgErr.Msg("first")
+	wrapped := err.(*Error).Msg("second")
+	assert.Equal(t, "EArg: second", wrapped.Error())
+	assert.Equal(t, "EArg: first", err.Error())
+	assert.True(t, errors.Is(wrapped, ArgErr))
+	assert.True(t, errors.Is(fmt.Errorf("op: %w", wrapped), ArgErr))
 }
@@ -0,0 +1,175 @@
+package goagi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrFax error returned when fax transmission fails
+var ErrFax = newError("Fax")
+
+// Fax statuses of FAXSTATUS variable
+const (
+	FaxSuccess = "SUCCESS"
+	FaxFailed  = "FAILED"
+)
+
+// FaxOptions are common options of SendFax and ReceiveFax. Station and
+// rate options are set with FAXOPT function before transmission.
+type FaxOptions struct {
+	// LocalStationID sent to remote station
+	LocalStationID string
+	// HeaderInfo printed on sent pages
+	HeaderInfo string
+	// DisableECM disables error correction mode
+	DisableECM bool
+	// MinRate and MaxRate limit transmission rate, like 2400 or 14400
+	MinRate int
+	MaxRate int
+	// AudioFallback allows audio mode when T.38 negotiation fails (f)
+	AudioFallback bool
+	// Debug enables fax debug (d)
+	Debug bool
+}
+
+// SendFaxOptions options of SendFax
+type SendFaxOptions struct {
+	FaxOptions
+	// Answer runs fax session in answering mode (a). SendFAX is caller by default
+	Answer bool
+	// T38Reinvite initiates T.38 reinvite (z)
+	T38Reinvite bool
+}
+
+// ReceiveFaxOptions options of ReceiveFax
+type ReceiveFaxOptions struct {
+	FaxOptions
+	// Caller runs fax session in caller mode (c). ReceiveFAX answers by default
+	Caller bool
+	// ForceAudio disables T.38 (F)
+	ForceAudio bool
+}
+
+// FaxResult outcome of fax transmission
+type FaxResult struct {
+	// Status is FAXSTATUS: FaxSuccess or FaxFailed
+	Status string
+	// Error is FAXERROR with failure cause
+	Error           string
+	Pages           int
+	RemoteStationID string
+	Bitrate         int
+}
+
+// FaxError error returned when FAXSTATUS is not success
+type FaxError struct {
+	// Code is FAXERROR value, like "HANGUP" or "NO_FAX"
+	Code   string
+	Result *FaxResult
+}
+
+func (e *FaxError) Error() string {
+	return fmt.Sprintf("Fax: failed with status %q: %s", e.Result.Status, e.Code)
+}
+
+// Unwrap returns ErrFax and ErrHangup when remote hung up
+func (e *FaxError) Unwrap() []error {
+	if e.Code == "HANGUP" {
+		return []error{ErrFax, ErrHangup}
+	}
+	return []error{ErrFax}
+}
+
+/*
+SendFax sends TIFF files with SendFAX application. Files are sent as one
+fax, so file names can not contain "&" separator. Returns result and
+*FaxError when transmission failed.
+
+	res, err := agi.SendFax([]string{"/var/spool/fax/doc.tif"}, goagi.SendFaxOptions{
+		FaxOptions: goagi.FaxOptions{LocalStationID: "+15551234567", HeaderInfo: "ACME"}})
+*/
+func (agi *AGI) SendFax(files []string, opts SendFaxOptions) (*FaxResult, error) {
+	if len(files) == 0 {
+		return nil, ErrArgument.Msg("no fax files")
+	}
+	for _, file := range files {
+		if file == "" || strings.Contains(file, "&") {
+			return nil, ErrArgument.Msg("invalid fax file %q", file)
+		}
+	}
+	var o appOptions
+	o.flag(opts.Answer, "a")
+	o.flag(opts.Debug, "d")
+	o.flag(opts.AudioFallback, "f")
+	o.flag(opts.T38Reinvite, "z")
+	return agi.fax("SendFAX", playFiles(files), o, opts.FaxOptions)
+}
+
+// ReceiveFax receives fax to TIFF file with ReceiveFAX application.
+// Returns result and *FaxError when transmission failed.
+func (agi *AGI) ReceiveFax(file string, opts ReceiveFaxOptions) (*FaxResult, error) {
+	if file == "" {
+		return nil, ErrArgument.Msg("no fax file")
+	}
+	var o appOptions
+	o.flag(opts.Caller, "c")
+	o.flag(opts.Debug, "d")
+	o.flag(opts.AudioFallback, "f")
+	o.flag(opts.ForceAudio, "F")
+	return agi.fax("ReceiveFAX", appArg(file), o, opts.FaxOptions)
+}
+
+func (agi *AGI) fax(app, files string, o appOptions, opts FaxOptions) (*FaxResult, error) {
+	faxopt := [][2]string{
+		{"localstationid", opts.LocalStationID},
+		{"headerinfo", opts.HeaderInfo},
+	}
+	if opts.DisableECM {
+		faxopt = append(faxopt, [2]string{"ecm", "no"})
+	}
+	if opts.MinRate > 0 {
+		faxopt = append(faxopt, [2]string{"minrate", strconv.Itoa(opts.MinRate)})
+	}
+	if opts.MaxRate > 0 {
+		faxopt = append(faxopt, [2]string{"maxrate", strconv.Itoa(opts.MaxRate)})
+	}
+	for _, opt := range faxopt {
+		if opt[1] == "" {
+			continue
+		}
+		if err := agi.SetFunc("FAXOPT", opt[1], opt[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := agi.execApp(app, appArgs(files, o.String())); err != nil {
+		return nil, err
+	}
+	return agi.FaxResult()
+}
+
+// FaxResult reads result of the last fax transmission from FAXSTATUS,
+// FAXERROR, FAXPAGES, REMOTESTATIONID and FAXBITRATE variables.
+func (agi *AGI) FaxResult() (*FaxResult, error) {
+	vars, err := agi.GetVariables("FAXSTATUS", "FAXERROR", "FAXPAGES",
+		"REMOTESTATIONID", "FAXBITRATE")
+	if err != nil {
+		return nil, err
+	}
+	res := &FaxResult{
+		Status:          vars["FAXSTATUS"],
+		Error:           vars["FAXERROR"],
+		RemoteStationID: strings.TrimSpace(vars["REMOTESTATIONID"]),
+	}
+	res.Pages, _ = strconv.Atoi(vars["FAXPAGES"])
+	res.Bitrate, _ = strconv.Atoi(vars["FAXBITRATE"])
+	if res.Status != FaxSuccess {
+		code := res.Error
+		if code == "" {
+			code = "UNKNOWN"
+		}
+		return res, &FaxError{Code: code, Result: res}
+	}
+	return res, nil
+}
@@ -0,0 +1,93 @@
+package goagi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendFax(t *testing.T) {
+	agi, buf := mockAGIScript(respOk, respOk, respOk, "200 result=0",
+		"200 result=1 (SUCCESS)", "200 result=1 (OK)", "200 result=1 (3)",
+		"200 result=1 ( +15550001111)", "200 result=1 (14400)")
+	res, err := agi.SendFax([]string{"/tmp/a.tif", "/tmp/b.tif"}, SendFaxOptions{
+		FaxOptions: FaxOptions{
+			LocalStationID: "+15551234567",
+			HeaderInfo:     "ACME, Inc",
+			MaxRate:        9600,
+		},
+		T38Reinvite: true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, &FaxResult{FaxSuccess, "OK", 3, "+15550001111", 14400}, res)
+	assert.Equal(t,
+		"SET VARIABLE \"FAXOPT(localstationid)\" \"+15551234567\"\n"+
+			"SET VARIABLE \"FAXOPT(headerinfo)\" \"ACME, Inc\"\n"+
+			"SET VARIABLE \"FAXOPT(maxrate)\" \"9600\"\n"+
+			"EXEC SendFAX \"/tmp/a.tif&/tmp/b.tif,z\"\n"+
+			"GET VARIABLE FAXSTATUS\nGET VARIABLE FAXERROR\nGET VARIABLE FAXPAGES\n"+
+			"GET VARIABLE REMOTESTATIONID\nGET VARIABLE FAXBITRATE\n",
+		buf.String())
+
+	agi, buf = mockAGIScript("200 result=0", "200 result=1 (SUCCESS)", "200 result=1 (OK)",
+		"200 result=1 (1)", "200 result=0", "200 result=0")
+	_, err = agi.SendFax([]string{"/tmp/a.tif"},
+		SendFaxOptions{FaxOptions: FaxOptions{Debug: true}, Answer: true})
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "EXEC SendFAX \"/tmp/a.tif,ad\"\n")
+
+	agi, buf = mockAGI(respOk)
+	for _, files := range [][]string{nil, {""}, {"/tmp/a&b.tif"}, {"/tmp/a.tif", "/tmp/b&c.tif"}} {
+		_, err = agi.SendFax(files, SendFaxOptions{})
+		assert.True(t, errors.Is(err, ErrArgument), files)
+	}
+	assert.Empty(t, buf.String())
+}
+
+func TestReceiveFax(t *testing.T) {
+	agi, buf := mockAGIScript(respOk, "200 result=0",
+		"200 result=1 (FAILED)", "200 result=1 (NO_FAX)", "200 result=1 (0)",
+		"200 result=0", "200 result=0")
+	res, err := agi.ReceiveFax("/var/spool/fax/in.tif",
+		ReceiveFaxOptions{FaxOptions: FaxOptions{DisableECM: true}, ForceAudio: true, Caller: true})
+	assert.NotNil(t, err)
+	assert.Equal(t, &FaxResult{Status: FaxFailed, Error: "NO_FAX"}, res)
+	var ferr *FaxError
+	assert.True(t, errors.As(err, &ferr))
+	assert.Equal(t, "NO_FAX", ferr.Code)
+	assert.Equal(t, res, ferr.Result)
+	assert.True(t, errors.Is(err, ErrFax))
+	assert.False(t, errors.Is(err, ErrHangup))
+	assert.Equal(t,
+		"SET VARIABLE \"FAXOPT(ecm)\" \"no\"\n"+
+			"EXEC ReceiveFAX \"/var/spool/fax/in.tif,cF\"\n"+
+			"GET VARIABLE FAXSTATUS\nGET VARIABLE FAXERROR\nGET VARIABLE FAXPAGES\n"+
+			"GET VARIABLE REMOTESTATIONID\nGET VARIABLE FAXBITRATE\n",
+		buf.String())
+}
+
+func TestFaxFail(t *testing.T) {
+	agi, _ := mockAGIScript("200 result=0", "200 result=1 (FAILED)",
+		"200 result=1 (HANGUP)", "200 result=1 (1)", "200 result=0", "200 result=0")
+	_, err := agi.ReceiveFax("/tmp/in.tif", ReceiveFaxOptions{})
+	assert.True(t, errors.Is(err, ErrFax))
+	assert.True(t, errors.Is(err, ErrHangup))
+	assert.Contains(t, err.Error(), "HANGUP")
+
+	// status not set
+	agi, _ = mockAGIScript("200 result=0", "200 result=0", "200 result=0",
+		"200 result=0", "200 result=0", "200 result=0")
+	_, err = agi.ReceiveFax("/tmp/in.tif", ReceiveFaxOptions{})
+	var ferr *FaxError
+	assert.True(t, errors.As(err, &ferr))
+	assert.Equal(t, "UNKNOWN", ferr.Code)
+
+	agi, _ = mockAGI("200 result=-2")
+	_, err = agi.ReceiveFax("/tmp/in.tif", ReceiveFaxOptions{})
+	assert.True(t, errors.Is(err, ErrCommand))
+	assert.False(t, errors.Is(err, ErrFax))
+
+	_, err = agi.ReceiveFax("", ReceiveFaxOptions{})
+	assert.True(t, errors.Is(err, ErrArgument))
+}
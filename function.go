@@ -0,0 +1,220 @@
+package goagi
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CallerID items of CALLERID dialplan function
+const (
+	CallerIDName  = "name"
+	CallerIDNum   = "num"
+	CallerIDAll   = "all"
+	CallerIDANI   = "ani"
+	CallerIDDNID  = "dnid"
+	CallerIDRDNIS = "rdnis"
+	CallerIDPres  = "pres"
+	CallerIDTag   = "tag"
+)
+
+// quoteArg quotes AGI command argument, so it can contain spaces
+func quoteArg(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// argEscaper escapes dialplan function and application argument
+var argEscaper = strings.NewReplacer(`\`, `\\`, `,`, `\,`, `(`, `\(`, `)`, `\)`,
+	`"`, `\"`, `|`, `\|`)
+
+/*
+escapeFuncArg escapes dialplan function argument. Separators, parentheses
+and quotes are escaped with backslash. Asterisk can not escape variable
+substitution and closing brace, so arguments with them are rejected.
+*/
+func escapeFuncArg(arg string) (string, error) {
+	if strings.Contains(arg, "${") || strings.Contains(arg, "$[") ||
+		strings.ContainsAny(arg, "}\r\n") {
+		return "", ErrArgument.Msg("invalid function argument %q", arg)
+	}
+	return argEscaper.Replace(arg), nil
+}
+
+// funcExpr returns dialplan function call "NAME(arg1,arg2)"
+func funcExpr(name string, args ...string) (string, error) {
+	escaped := make([]string, len(args))
+	for i, arg := range args {
+		esc, err := escapeFuncArg(arg)
+		if err != nil {
+			return "", err
+		}
+		escaped[i] = esc
+	}
+	return name + "(" + strings.Join(escaped, ",") + ")", nil
+}
+
+/*
+Eval evaluates dialplan expression with GetFullVariable and returns
+its value. Expression is quoted, so it can contain spaces.
+
+	val, err := agi.Eval("${CALLERID(num)}@${CONTEXT}")
+*/
+func (agi *AGI) Eval(expr string) (string, error) {
+	resp, err := checkResponse(agi.GetFullVariable(quoteArg(expr), ""))
+	if err != nil {
+		return "", err
+	}
+	if resp.Result() != 1 {
+		return "", ErrCommand.Msg("failed to evaluate %q", expr)
+	}
+	return resp.Value(), nil
+}
+
+// Func reads dialplan function value. Arguments are escaped.
+func (agi *AGI) Func(name string, args ...string) (string, error) {
+	expr, err := funcExpr(name, args...)
+	if err != nil {
+		return "", err
+	}
+	return agi.Eval("${" + expr + "}")
+}
+
+// SetFunc writes value to dialplan function. Arguments are escaped and
+// function expression is quoted, so arguments may contain spaces.
+func (agi *AGI) SetFunc(name, value string, args ...string) error {
+	expr, err := funcExpr(name, args...)
+	if err != nil {
+		return err
+	}
+	_, err = checkResponse(agi.SetVariable(quoteArg(expr), value))
+	return err
+}
+
+// CallerIDItem returns CALLERID item, like CallerIDNum
+func (agi *AGI) CallerIDItem(item string) (string, error) {
+	return agi.Func("CALLERID", item)
+}
+
+// SetCallerIDItem sets CALLERID item
+func (agi *AGI) SetCallerIDItem(item, value string) error {
+	return agi.SetFunc("CALLERID", value, item)
+}
+
+// CDR returns CDR field value. Use CDRDuration for duration fields.
+func (agi *AGI) CDR(field string) (string, error) {
+	return agi.Func("CDR", field)
+}
+
+// SetCDR sets CDR field, like userfield or accountcode
+func (agi *AGI) SetCDR(field, value string) error {
+	return agi.SetFunc("CDR", value, field)
+}
+
+// CDRDuration returns CDR duration or billsec field as duration
+func (agi *AGI) CDRDuration(field string) (time.Duration, error) {
+	val, err := agi.Func("CDR", field, "f")
+	if err != nil {
+		return 0, err
+	}
+	if val == "" {
+		return 0, nil
+	}
+	sec, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return 0, ErrCommand.Msg("invalid CDR(%s) value %q", field, val)
+	}
+	return time.Duration(sec * float64(time.Second)), nil
+}
+
+// Hash returns value of HASH key
+func (agi *AGI) Hash(name, key string) (string, error) {
+	return agi.Func("HASH", name, key)
+}
+
+// SetHash sets value of HASH key
+func (agi *AGI) SetHash(name, key, value string) error {
+	return agi.SetFunc("HASH", value, name, key)
+}
+
+// HashKeys returns keys of HASH
+func (agi *AGI) HashKeys(name string) ([]string, error) {
+	val, err := agi.Func("HASHKEYS", name)
+	if err != nil || val == "" {
+		return nil, err
+	}
+	return strings.Split(val, ","), nil
+}
+
+// HashMap returns all HASH keys with values
+func (agi *AGI) HashMap(name string) (map[string]string, error) {
+	keys, err := agi.HashKeys(name)
+	if err != nil {
+		return nil, err
+	}
+	hash := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if hash[key], err = agi.Hash(name, key); err != nil {
+			return nil, err
+		}
+	}
+	return hash, nil
+}
+
+// PJSIPHeader returns header of the inbound PJSIP request
+func (agi *AGI) PJSIPHeader(name string) (string, error) {
+	return agi.Func("PJSIP_HEADER", "read", name)
+}
+
+// AddPJSIPHeader adds header to the outbound PJSIP request. Must be
+// called on the outbound channel, for example from pre-dial handler.
+func (agi *AGI) AddPJSIPHeader(name, value string) error {
+	return agi.SetFunc("PJSIP_HEADER", value, "add", name)
+}
+
+// SIPHeader returns header of the inbound chan_sip request
+func (agi *AGI) SIPHeader(name string) (string, error) {
+	return agi.Func("SIP_HEADER", name)
+}
+
+// AddSIPHeader adds header to the outbound chan_sip request with
+// SIPAddHeader application
+func (agi *AGI) AddSIPHeader(name, value string) error {
+	if strings.ContainsAny(name, ": \r\n") || strings.ContainsAny(value, "\r\n") {
+		return ErrArgument.Msg("invalid SIP header %q: %q", name, value)
+	}
+	header := strings.ReplaceAll(name+": "+value, ",", `\,`)
+	resp, err := checkResponse(agi.Exec("SIPAddHeader", header))
+	if err != nil {
+		return err
+	}
+	if resp.Result() == -2 {
+		return ErrCommand.Msg("application SIPAddHeader not found")
+	}
+	return nil
+}
+
+// ChannelItem returns CHANNEL function item, like "peerip" or "rtt"
+func (agi *AGI) ChannelItem(item string) (string, error) {
+	return agi.Func("CHANNEL", item)
+}
+
+// SetChannelItem sets CHANNEL function item, like "language"
+func (agi *AGI) SetChannelItem(item, value string) error {
+	return agi.SetFunc("CHANNEL", value, item)
+}
+
+// JSONDecode returns item of JSON stored in channel variable. Nested
+// items are separated with dot: "customer.name"
+func (agi *AGI) JSONDecode(variable, item string) (string, error) {
+	return agi.Func("JSON_DECODE", variable, item)
+}
+
+// DBExists returns true if AstDB key exists
+func (agi *AGI) DBExists(family, key string) (bool, error) {
+	val, err := agi.Func("DB_EXISTS", family+"/"+key)
+	if err != nil {
+		return false, err
+	}
+	return val == "1", nil
+}
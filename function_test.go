@@ -0,0 +1,153 @@
+package goagi
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeFuncArg(t *testing.T) {
+	tests := map[string]string{
+		"num":          "num",
+		"a,b":          `a\,b`,
+		"f(x)":         `f\(x\)`,
+		`say "hi"`:     `say \"hi\"`,
+		`back\slash`:   `back\\slash`,
+		"pipe|pipe":    `pipe\|pipe`,
+		"$dollar only": "$dollar only",
+	}
+	for in, want := range tests {
+		got, err := escapeFuncArg(in)
+		assert.Nil(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	for _, in := range []string{"${EXTEN}", "$[1+1]", "a}b", "a\nb"} {
+		_, err := escapeFuncArg(in)
+		assert.True(t, errors.Is(err, ErrArgument), in)
+	}
+}
+
+func TestEval(t *testing.T) {
+	agi, buf := mockAGI("200 result=1 (Alice (work))")
+	val, err := agi.Eval("${CALLERID(name)} ${CONTEXT}")
+	assert.Nil(t, err)
+	assert.Equal(t, "Alice (work)", val)
+	assert.Equal(t, "GET FULL VARIABLE \"${CALLERID(name)} ${CONTEXT}\"\n", buf.String())
+
+	agi, _ = mockAGI("200 result=0")
+	_, err = agi.Eval("${FOO}")
+	assert.True(t, errors.Is(err, ErrCommand))
+
+	agi, _ = mockAGI("511 Command Not Permitted on a dead channel")
+	_, err = agi.Eval("${FOO}")
+	assert.True(t, errors.Is(err, ErrCommand))
+}
+
+func TestFuncRead(t *testing.T) {
+	tests := []struct {
+		call func(*AGI) (string, error)
+		cmd  string
+	}{
+		{func(a *AGI) (string, error) { return a.CallerIDItem(CallerIDNum) },
+			`${CALLERID(num)}`},
+		{func(a *AGI) (string, error) { return a.CDR("userfield") },
+			`${CDR(userfield)}`},
+		{func(a *AGI) (string, error) { return a.Hash("cust", "last, first") },
+			`${HASH(cust,last\\, first)}`},
+		{func(a *AGI) (string, error) { return a.PJSIPHeader("X-Account") },
+			`${PJSIP_HEADER(read,X-Account)}`},
+		{func(a *AGI) (string, error) { return a.SIPHeader("X-Account") },
+			`${SIP_HEADER(X-Account)}`},
+		{func(a *AGI) (string, error) { return a.ChannelItem("peerip") },
+			`${CHANNEL(peerip)}`},
+		{func(a *AGI) (string, error) { return a.JSONDecode("RESP", "customer.name") },
+			`${JSON_DECODE(RESP,customer.name)}`},
+	}
+	for _, tc := range tests {
+		agi, buf := mockAGI("200 result=1 (value)")
+		val, err := tc.call(agi)
+		assert.Nil(t, err)
+		assert.Equal(t, "value", val)
+		assert.Equal(t, "GET FULL VARIABLE \""+tc.cmd+"\"\n", buf.String())
+	}
+
+	agi, buf := mockAGI("200 result=1 (value)")
+	_, err := agi.Hash("cust", "${SHELL(rm)}")
+	assert.True(t, errors.Is(err, ErrArgument))
+	assert.Empty(t, buf.String())
+}
+
+func TestFuncWrite(t *testing.T) {
+	tests := []struct {
+		call func(*AGI) error
+		cmd  string
+	}{
+		{func(a *AGI) error { return a.SetCallerIDItem(CallerIDName, "Bob") },
+			`SET VARIABLE "CALLERID(name)" "Bob"`},
+		{func(a *AGI) error { return a.SetCDR("userfield", "vip") },
+			`SET VARIABLE "CDR(userfield)" "vip"`},
+		{func(a *AGI) error { return a.SetHash("cust", "id", "42") },
+			`SET VARIABLE "HASH(cust,id)" "42"`},
+		{func(a *AGI) error { return a.SetHash("my cust", "first name", "Bob") },
+			`SET VARIABLE "HASH(my cust,first name)" "Bob"`},
+		{func(a *AGI) error { return a.AddPJSIPHeader("X-Account", "a b") },
+			`SET VARIABLE "PJSIP_HEADER(add,X-Account)" "a b"`},
+		{func(a *AGI) error { return a.SetChannelItem("language", "fr") },
+			`SET VARIABLE "CHANNEL(language)" "fr"`},
+		{func(a *AGI) error { return a.AddSIPHeader("X-Tags", "a,b") },
+			`EXEC SIPAddHeader "X-Tags: a\\,b"`},
+	}
+	for _, tc := range tests {
+		agi, buf := mockAGI("200 result=0")
+		assert.Nil(t, tc.call(agi))
+		assert.Equal(t, tc.cmd+"\n", buf.String())
+	}
+
+	agi, _ := mockAGI("200 result=-2")
+	assert.True(t, errors.Is(agi.AddSIPHeader("X-Foo", "bar"), ErrCommand))
+	assert.True(t, errors.Is(agi.AddSIPHeader("X-Foo:", "bar"), ErrArgument))
+}
+
+func TestCDRDuration(t *testing.T) {
+	agi, buf := mockAGI("200 result=1 (12.5)")
+	d, err := agi.CDRDuration("billsec")
+	assert.Nil(t, err)
+	assert.Equal(t, 12500*time.Millisecond, d)
+	assert.Equal(t, "GET FULL VARIABLE \"${CDR(billsec,f)}\"\n", buf.String())
+
+	agi, _ = mockAGI("200 result=1 (abc)")
+	_, err = agi.CDRDuration("billsec")
+	assert.True(t, errors.Is(err, ErrCommand))
+}
+
+func TestHashMap(t *testing.T) {
+	agi, buf := mockAGIScript("200 result=1 (id,name)",
+		"200 result=1 (42)", "200 result=1 (Bob)")
+	hash, err := agi.HashMap("cust")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"id": "42", "name": "Bob"}, hash)
+	assert.Equal(t, "GET FULL VARIABLE \"${HASHKEYS(cust)}\"\n"+
+		"GET FULL VARIABLE \"${HASH(cust,id)}\"\n"+
+		"GET FULL VARIABLE \"${HASH(cust,name)}\"\n", buf.String())
+
+	agi, _ = mockAGI("200 result=1 ()")
+	keys, err := agi.HashKeys("empty")
+	assert.Nil(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestDBExists(t *testing.T) {
+	agi, buf := mockAGI("200 result=1 (1)")
+	ok, err := agi.DBExists("blacklist", "5551234")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "GET FULL VARIABLE \"${DB_EXISTS(blacklist/5551234)}\"\n", buf.String())
+
+	agi, _ = mockAGI("200 result=1 (0)")
+	ok, err = agi.DBExists("blacklist", "5550000")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
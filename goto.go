@@ -0,0 +1,156 @@
+package goagi
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrGoto error returned when dialplan continuation can not be set
+var ErrGoto = newError("Goto")
+
+// GotoError reports part of Goto target that failed
+type GotoError struct {
+	// Part is "context", "extension" or "priority"
+	Part string
+	// Value of the failed part
+	Value string
+	// Err is cause of the failure
+	Err error
+}
+
+func (e *GotoError) Error() string {
+	return fmt.Sprintf("Goto: failed to set %s %q: %s", e.Part, e.Value, e.Err)
+}
+
+// Unwrap returns ErrGoto and the failure cause
+func (e *GotoError) Unwrap() []error { return []error{ErrGoto, e.Err} }
+
+/*
+Goto sets context, extension and priority to continue in dialplan
+when AGI exits. Empty context or extension keeps the current one and
+empty priority is set to "1". Priority can be a number or label.
+
+Commands act as one operation: current values are read first and parts
+that were already set are restored when a later command fails. The
+failure is returned as *GotoError. Use GotoVerified to check that target
+exists before changing anything.
+*/
+func (agi *AGI) Goto(context, exten, priority string) error {
+	if priority == "" {
+		priority = "1"
+	}
+	parts := []gotoPart{
+		{"context", "CONTEXT", context, agi.SetContext},
+		{"extension", "EXTEN", exten, agi.SetExtension},
+		{"priority", "PRIORITY", priority, agi.SetPriority},
+	}
+	// validate all parts before changing anything
+	set := make([]gotoPart, 0, len(parts))
+	for _, part := range parts {
+		if err := gotoCheck(part.value); err != nil {
+			return &GotoError{part.name, part.value, err}
+		}
+		if part.value != "" {
+			set = append(set, part)
+		}
+	}
+	// save current values of parts that may need rollback
+	saved := make([]string, len(set)-1)
+	for i, part := range set[:len(set)-1] {
+		val, _, err := agi.Variable(part.variable)
+		if err != nil {
+			return &GotoError{part.name, part.value, err}
+		}
+		saved[i] = val
+	}
+	for i, part := range set {
+		if err := part.apply(part.value); err != nil {
+			return &GotoError{part.name, part.value, errors.Join(err, agi.gotoRestore(set[:i], saved))}
+		}
+	}
+	return nil
+}
+
+// gotoPart is a part of Goto target with channel variable of current value
+type gotoPart struct {
+	name     string
+	variable string
+	value    string
+	set      func(string) (Response, error)
+}
+
+func (p gotoPart) apply(value string) error {
+	resp, err := checkResponse(p.set(value))
+	if err == nil && resp.Result() < 0 {
+		err = ErrCommand.Msg("%s", resp.RawResponse())
+	}
+	return err
+}
+
+// gotoRestore sets saved values of parts in reverse order
+func (agi *AGI) gotoRestore(parts []gotoPart, saved []string) error {
+	for i := len(parts) - 1; i >= 0; i-- {
+		if saved[i] == "" {
+			continue
+		}
+		if err := parts[i].apply(saved[i]); err != nil {
+			return ErrGoto.Msg("failed to restore %s %q: %s", parts[i].name, saved[i], err)
+		}
+	}
+	return nil
+}
+
+/*
+GotoVerified checks target with ValidExten and calls Goto only if it
+exists. Otherwise returns *GotoError for the extension and channel
+continuation is not changed.
+*/
+func (agi *AGI) GotoVerified(context, exten, priority string) error {
+	if priority == "" {
+		priority = "1"
+	}
+	ok, err := agi.ValidExten(context, exten, priority)
+	if err != nil {
+		return &GotoError{"extension", exten, err}
+	}
+	if !ok {
+		target := fmt.Sprintf("%s,%s,%s", context, exten, priority)
+		return &GotoError{"extension", exten,
+			ErrArgument.Msg("target %q does not exist", target)}
+	}
+	return agi.Goto(context, exten, priority)
+}
+
+/*
+ValidExten returns true if extension with priority or label exists in
+context, using VALID_EXTEN dialplan function. Empty context or extension
+is replaced with the current channel value.
+*/
+func (agi *AGI) ValidExten(context, exten, priority string) (bool, error) {
+	args := []string{"${CONTEXT}", "${EXTEN}", priority}
+	for i, val := range []string{context, exten, priority} {
+		if val == "" {
+			continue
+		}
+		esc, err := escapeFuncArg(val)
+		if err != nil {
+			return false, err
+		}
+		args[i] = esc
+	}
+	val, err := agi.Eval(fmt.Sprintf("${VALID_EXTEN(%s,%s,%s)}", args[0], args[1], args[2]))
+	if err != nil {
+		return false, err
+	}
+	return val == "1", nil
+}
+
+// gotoCheck validates Goto part can be sent as single command argument
+func gotoCheck(value string) error {
+	for _, c := range value {
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '"' {
+			return ErrArgument.Msg("invalid character %q", c)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package goagi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoto(t *testing.T) {
+	agi, buf := mockAGIScript("200 result=1 (default)", "200 result=1 (s)",
+		"200 result=0", "200 result=0", "200 result=0")
+	assert.Nil(t, agi.Goto("sales", "100", "start"))
+	assert.Equal(t, "GET VARIABLE CONTEXT\nGET VARIABLE EXTEN\n"+
+		"SET CONTEXT sales\nSET EXTENSION 100\nSET PRIORITY start\n",
+		buf.String())
+
+	agi, buf = mockAGIScript("200 result=0")
+	assert.Nil(t, agi.Goto("", "", ""))
+	assert.Equal(t, "SET PRIORITY 1\n", buf.String())
+}
+
+func TestGotoFail(t *testing.T) {
+	agi, buf := mockAGIScript("200 result=1 (default)", "200 result=1 (s)",
+		"200 result=0", "200 result=0",
+		"520 Invalid command syntax.  Proper usage not available.",
+		"200 result=0", "200 result=0")
+	err := agi.Goto("sales", "100", "nolabel")
+	var gerr *GotoError
+	assert.True(t, errors.As(err, &gerr))
+	assert.Equal(t, "priority", gerr.Part)
+	assert.Equal(t, "nolabel", gerr.Value)
+	assert.True(t, errors.Is(err, ErrGoto))
+	assert.True(t, errors.Is(err, ErrCommand))
+	assert.Contains(t, err.Error(), `failed to set priority "nolabel"`)
+	// context and extension are restored
+	assert.Equal(t, "GET VARIABLE CONTEXT\nGET VARIABLE EXTEN\n"+
+		"SET CONTEXT sales\nSET EXTENSION 100\nSET PRIORITY nolabel\n"+
+		"SET EXTENSION s\nSET CONTEXT default\n",
+		buf.String())
+
+	// without extension only context is restored
+	agi, buf = mockAGIScript("200 result=1 (default)",
+		"200 result=0", "200 result=-1", "200 result=0")
+	err = agi.Goto("sales", "", "1")
+	assert.True(t, errors.As(err, &gerr))
+	assert.Equal(t, "priority", gerr.Part)
+	assert.Equal(t, "GET VARIABLE CONTEXT\n"+
+		"SET CONTEXT sales\nSET PRIORITY 1\nSET CONTEXT default\n", buf.String())
+
+	// restore failure is reported
+	agi, _ = mockAGIScript("200 result=1 (default)", "200 result=0",
+		"200 result=-1", "511 Command Not Permitted on a dead channel")
+	err = agi.Goto("sales", "", "1")
+	assert.True(t, errors.Is(err, ErrGoto))
+	assert.Contains(t, err.Error(), `failed to restore context "default"`)
+
+	// current value can not be read
+	agi, buf = mockAGIScript("511 Command Not Permitted on a dead channel")
+	err = agi.Goto("sales", "100", "1")
+	assert.True(t, errors.As(err, &gerr))
+	assert.Equal(t, "context", gerr.Part)
+	assert.Equal(t, "GET VARIABLE CONTEXT\n", buf.String())
+
+	agi, buf = mockAGI("200 result=0")
+	err = agi.Goto("sales", "100 200", "1")
+	assert.True(t, errors.As(err, &gerr))
+	assert.Equal(t, "extension", gerr.Part)
+	assert.True(t, errors.Is(err, ErrArgument))
+	assert.Empty(t, buf.String())
+}
+
+func TestGotoVerified(t *testing.T) {
+	agi, buf := mockAGIScript("200 result=1 (1)", "200 result=1 (default)",
+		"200 result=1 (s)", "200 result=0", "200 result=0", "200 result=0")
+	assert.Nil(t, agi.GotoVerified("sales", "100", "start"))
+	assert.Equal(t, "GET FULL VARIABLE \"${VALID_EXTEN(sales,100,start)}\"\n"+
+		"GET VARIABLE CONTEXT\nGET VARIABLE EXTEN\nSET CONTEXT sales\nSET EXTENSION 100\nSET PRIORITY start\n", buf.String())
+
+	agi, buf = mockAGI("200 result=1 (0)")
+	err := agi.GotoVerified("", "999", "")
+	var gerr *GotoError
+	assert.True(t, errors.As(err, &gerr))
+	assert.Equal(t, "extension", gerr.Part)
+	assert.True(t, errors.Is(err, ErrGoto))
+	assert.Equal(t, "GET FULL VARIABLE \"${VALID_EXTEN(${CONTEXT},999,1)}\"\n",
+		buf.String())
+
+	agi, _ = mockAGI("511 Command Not Permitted on a dead channel")
+	err = agi.GotoVerified("sales", "100", "1")
+	assert.True(t, errors.Is(err, ErrCommand))
+}
@@ -0,0 +1,270 @@
+package goagi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StopReason is a reason why playback or recording stopped
+type StopReason int
+
+// Reasons of playback or recording stop
+const (
+	// StopFinished playback finished without interruption
+	StopFinished StopReason = iota
+	// StopHangup channel hung up
+	StopHangup
+	// StopDTMF interrupted by DTMF digit
+	StopDTMF
+	// StopTimeout maximum time reached
+	StopTimeout
+	// StopSilence silence detected. Asterisk reports it as timeout, so it
+	// is an approximate guess made when silence detection is enabled and
+	// recording is shorter than timeout
+	StopSilence
+)
+
+// String returns stop reason name
+func (r StopReason) String() string {
+	switch r {
+	case StopFinished:
+		return "finished"
+	case StopHangup:
+		return "hangup"
+	case StopDTMF:
+		return "dtmf"
+	case StopTimeout:
+		return "timeout"
+	case StopSilence:
+		return "silence"
+	}
+	return fmt.Sprintf("unknown(%d)", int(r))
+}
+
+// MediaResult is typed result of playback and recording commands
+type MediaResult struct {
+	// Reason why playback or recording stopped. StopSilence is approximate:
+	// recording stopped by silence and timeout are both reported by
+	// Asterisk as timeout and told apart by the recording length only
+	Reason StopReason
+	// Digit that stopped playback or recording
+	Digit string
+	// EndPos is an offset in samples where playback or recording stopped
+	EndPos int64
+	// Response is AGI command response
+	Response Response
+}
+
+/*
+ControlStreamOptions options for ControlStreamFileOpts. Zero values mean
+Asterisk defaults.
+*/
+type ControlStreamOptions struct {
+	// Stop digits that stop playback
+	Stop Digits
+	// Skip is time to skip when forward or rewind digit is pressed (3s by default)
+	Skip time.Duration
+	// Forward digit to fast forward ("#" by default)
+	Forward string
+	// Rewind digit to rewind ("*" by default)
+	Rewind string
+	// Pause digit to pause and resume playback
+	Pause string
+	// Offset to start playback from
+	Offset time.Duration
+}
+
+func (o ControlStreamOptions) args() ([]string, error) {
+	if err := o.Stop.Validate(); err != nil {
+		return nil, err
+	}
+	if o.Skip < 0 || o.Offset < 0 {
+		return nil, ErrArgument.Msg("negative skip or offset")
+	}
+	used := string(o.Stop)
+	for _, d := range []string{o.Forward, o.Rewind, o.Pause} {
+		if d == "" {
+			continue
+		}
+		if len(d) != 1 || !IsDTMF(rune(d[0])) {
+			return nil, ErrArgument.Msg("control must be single DTMF digit: %q", d)
+		}
+		if strings.Contains(used, d) {
+			return nil, ErrArgument.Msg("digit %q is used more than once", d)
+		}
+		used += d
+	}
+
+	args := []string{"", o.Forward, o.Rewind, o.Pause, ""}
+	if o.Skip > 0 {
+		args[0] = fmt.Sprintf("%d", durationMs(o.Skip))
+	}
+	if o.Offset > 0 {
+		args[4] = fmt.Sprintf("%d", durationMs(o.Offset))
+	}
+	for len(args) > 0 && args[len(args)-1] == "" {
+		args = args[:len(args)-1]
+	}
+	return args, nil
+}
+
+/*
+ControlStreamFileOpts sends audio file on channel and allows the listener to
+control the stream with digits set in options. Produces the same command as
+ControlStreamFile.
+
+Example:
+
+	res, err := agi.ControlStreamFileOpts("prompt_en", goagi.ControlStreamOptions{
+		Stop:   "19",
+		Pause:  "#",
+		Offset: 1600 * time.Millisecond,
+	})
+*/
+func (agi *AGI) ControlStreamFileOpts(filename string, opts ControlStreamOptions) (*MediaResult, error) {
+	args, err := opts.args()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := checkResponse(agi.ControlStreamFile(filename, string(opts.Stop), args...))
+	if err != nil {
+		return nil, err
+	}
+
+	return newStreamResult(resp), nil
+}
+
+// newStreamResult creates result of playback command that returns
+// digit ASCII code in result field
+func newStreamResult(resp Response) *MediaResult {
+	res := &MediaResult{EndPos: resp.EndPos(), Response: resp}
+	switch {
+	case resp.Result() < 0:
+		res.Reason = StopHangup
+	case resp.Result() > 0:
+		res.Reason = StopDTMF
+		res.Digit = string(rune(resp.Result()))
+	}
+	return res
+}
+
+// RecordOptions options for RecordFileOpts
+type RecordOptions struct {
+	// Format of the file, "wav" by default
+	Format string
+	// Escape digits that stop recording
+	Escape Digits
+	// Timeout is maximum record time. Zero records without limit
+	Timeout time.Duration
+	// Offset samples to seek in the file before recording
+	Offset int
+	// Beep plays beep before recording
+	Beep bool
+	// Silence stops recording after given time of silence. Rounded up to seconds
+	Silence time.Duration
+}
+
+/*
+silenceStop returns true if recording that was reported as timeout was
+stopped by silence: silence detection was enabled and recorded audio is
+shorter than timeout.
+*/
+func (o RecordOptions) silenceStop(format string, endpos int64) bool {
+	if o.Silence <= 0 {
+		return false
+	}
+	if o.Timeout <= 0 {
+		return true
+	}
+	limit := int64(o.Timeout.Seconds() * float64(formatSampleRate(format)))
+	return endpos-int64(o.Offset) < limit
+}
+
+// formatSampleRate returns sample rate of Asterisk file format
+func formatSampleRate(format string) int {
+	switch format {
+	case "g722", "siren7":
+		return 16000
+	case "siren14":
+		return 32000
+	case "opus":
+		return 48000
+	}
+	for _, prefix := range []string{"wav", "slin", "sln"} {
+		if !strings.HasPrefix(format, prefix) {
+			continue
+		}
+		khz, err := strconv.Atoi(format[len(prefix):])
+		if err != nil {
+			break
+		}
+		if khz == 44 {
+			return 44100
+		}
+		return khz * 1000
+	}
+	return 8000
+}
+
+func (o RecordOptions) validate() error {
+	if strings.ContainsAny(o.Format, " \t\n") {
+		return ErrArgument.Msg("invalid format: %q", o.Format)
+	}
+	if o.Offset < 0 || o.Silence < 0 {
+		return ErrArgument.Msg("negative offset or silence")
+	}
+	return o.Escape.Validate()
+}
+
+/*
+RecordFileOpts records to a file with options. Produces the same command as
+RecordFile and returns why recording stopped.
+
+Example:
+
+	res, err := agi.RecordFileOpts("/tmp/msg", goagi.RecordOptions{
+		Escape:  "#",
+		Timeout: time.Minute,
+		Beep:    true,
+		Silence: 5 * time.Second,
+	})
+*/
+func (agi *AGI) RecordFileOpts(file string, opts RecordOptions) (*MediaResult, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(file) == "" {
+		return nil, ErrArgument.Msg("empty file name")
+	}
+	format := opts.Format
+	if format == "" {
+		format = "wav"
+	}
+	resp, err := checkResponse(agi.RecordFileTimeout(file, format, string(opts.Escape),
+		opts.Timeout, opts.Offset, opts.Beep, opts.Silence))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &MediaResult{EndPos: resp.EndPos(), Response: resp}
+	switch resp.Value() {
+	case "hangup":
+		res.Reason = StopHangup
+	case "dtmf":
+		res.Reason = StopDTMF
+		res.Digit = resp.Data()
+	case "timeout":
+		// Asterisk reports silence stop as timeout
+		res.Reason = StopTimeout
+		if opts.silenceStop(format, resp.EndPos()) {
+			res.Reason = StopSilence
+		}
+	default:
+		if resp.Result() < 0 {
+			return res, ErrCommand.Msg("record failed: %s", resp.Value())
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,197 @@
+package goagi
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStopReasonString(t *testing.T) {
+	assert.Equal(t, "finished", StopFinished.String())
+	assert.Equal(t, "hangup", StopHangup.String())
+	assert.Equal(t, "dtmf", StopDTMF.String())
+	assert.Equal(t, "timeout", StopTimeout.String())
+	assert.Equal(t, "silence", StopSilence.String())
+	assert.Equal(t, "unknown(9)", StopReason(9).String())
+}
+
+func TestCmdControlStreamFileOpts(t *testing.T) {
+	tests := []struct {
+		opts     ControlStreamOptions
+		response string
+		cmd      string
+		reason   StopReason
+		digit    string
+		endpos   int64
+	}{
+		{
+			ControlStreamOptions{},
+			"200 result=0 endpos=10",
+			"CONTROL STREAM FILE welcome \"\"\n",
+			StopFinished, "", 10,
+		}, {
+			ControlStreamOptions{Stop: "123", Skip: 1500 * time.Millisecond},
+			"200 result=50 endpos=998877",
+			`CONTROL STREAM FILE welcome "123" "1500"` + "\n",
+			StopDTMF, "2", 998877,
+		}, {
+			ControlStreamOptions{Stop: "19", Pause: "#", Offset: 1600 * time.Millisecond},
+			"200 result=-1 endpos=0",
+			`CONTROL STREAM FILE welcome "19" "" "" "" "#" "1600"` + "\n",
+			StopHangup, "", 0,
+		}, {
+			ControlStreamOptions{
+				Stop: "123", Skip: 1500 * time.Millisecond,
+				Forward: "#", Rewind: "0", Pause: "*", Offset: 1600 * time.Millisecond,
+			},
+			"200 result=35 endpos=20",
+			`CONTROL STREAM FILE welcome "123" "1500" "#" "0" "*" "1600"` + "\n",
+			StopDTMF, "#", 20,
+		},
+	}
+
+	for _, tc := range tests {
+		agi, buf := mockAGI(tc.response)
+		res, err := agi.ControlStreamFileOpts("welcome", tc.opts)
+		assert.Nil(t, err, tc.cmd)
+		assert.Equal(t, tc.cmd, buf.String())
+		assert.Equal(t, tc.reason, res.Reason, tc.cmd)
+		assert.Equal(t, tc.digit, res.Digit, tc.cmd)
+		assert.Equal(t, tc.endpos, res.EndPos, tc.cmd)
+		assert.Equal(t, 200, res.Response.Code())
+	}
+}
+
+func TestCmdControlStreamFileOptsFail(t *testing.T) {
+	tests := []ControlStreamOptions{
+		{Stop: "1 2"},
+		{Skip: -time.Second},
+		{Offset: -time.Second},
+		{Forward: "##"},
+		{Rewind: "x"},
+		{Pause: " "},
+		{Forward: "\n"},
+		{Stop: "12#", Forward: "#"},
+		{Forward: "1", Pause: "1"},
+	}
+	for _, opts := range tests {
+		agi, buf := mockAGI(respOk)
+		res, err := agi.ControlStreamFileOpts("welcome", opts)
+		assert.Nil(t, res)
+		assert.True(t, errors.Is(err, ErrArgument), opts)
+		assert.Empty(t, buf.String())
+	}
+
+	agi, _ := mockAGI("511 Command Not Permitted on a dead channel")
+	res, err := agi.ControlStreamFileOpts("welcome", ControlStreamOptions{})
+	assert.Nil(t, res)
+	assert.True(t, errors.Is(err, ErrCommand))
+	assert.Contains(t, err.Error(), "511 Command Not Permitted")
+}
+
+func TestCmdRecordFileOpts(t *testing.T) {
+	tests := []struct {
+		opts     RecordOptions
+		response string
+		cmd      string
+		reason   StopReason
+		digit    string
+		endpos   int64
+	}{
+		{
+			RecordOptions{},
+			"200 result=0 (timeout) endpos=86435",
+			"RECORD FILE msg wav \"\" -1\n",
+			StopTimeout, "", 86435,
+		}, {
+			RecordOptions{Format: "gsm", Escape: "*#", Timeout: time.Second, Offset: 600},
+			"200 result=* (dtmf) endpos=1554",
+			"RECORD FILE msg gsm \"*#\" 1000 600\n",
+			StopDTMF, "*", 1554,
+		}, {
+			RecordOptions{Escape: "#", Beep: true, Silence: 5 * time.Second},
+			"200 result=-1 (hangup) endpos=0",
+			"RECORD FILE msg wav \"#\" -1 BEEP s=5\n",
+			StopHangup, "", 0,
+		}, {
+			RecordOptions{Silence: 2 * time.Second},
+			"200 result=0 (timeout) endpos=100",
+			"RECORD FILE msg wav \"\" -1 s=2\n",
+			StopSilence, "", 100,
+		}, {
+			RecordOptions{Silence: 2 * time.Second, Timeout: 10 * time.Second},
+			"200 result=0 (timeout) endpos=24000",
+			"RECORD FILE msg wav \"\" 10000 s=2\n",
+			StopSilence, "", 24000,
+		}, {
+			RecordOptions{Silence: 2 * time.Second, Timeout: 10 * time.Second},
+			"200 result=0 (timeout) endpos=80000",
+			"RECORD FILE msg wav \"\" 10000 s=2\n",
+			StopTimeout, "", 80000,
+		}, {
+			RecordOptions{Format: "wav16", Silence: 2 * time.Second, Timeout: 10 * time.Second},
+			"200 result=0 (timeout) endpos=120000",
+			"RECORD FILE msg wav16 \"\" 10000 s=2\n",
+			StopSilence, "", 120000,
+		}, {
+			RecordOptions{Timeout: TimeoutInfinite},
+			"200 result=0 endpos=100",
+			"RECORD FILE msg wav \"\" -1\n",
+			StopFinished, "", 100,
+		},
+	}
+
+	for _, tc := range tests {
+		agi, buf := mockAGI(tc.response)
+		res, err := agi.RecordFileOpts("msg", tc.opts)
+		assert.Nil(t, err, tc.cmd)
+		assert.Equal(t, tc.cmd, buf.String())
+		assert.Equal(t, tc.reason, res.Reason, tc.cmd)
+		assert.Equal(t, tc.digit, res.Digit, tc.cmd)
+		assert.Equal(t, tc.endpos, res.EndPos, tc.cmd)
+	}
+}
+
+func TestCmdRecordFileOptsFail(t *testing.T) {
+	tests := []RecordOptions{
+		{Format: "w av"},
+		{Offset: -1},
+		{Silence: -time.Second},
+		{Escape: "abc"},
+	}
+	for _, opts := range tests {
+		agi, buf := mockAGI(respOk)
+		res, err := agi.RecordFileOpts("msg", opts)
+		assert.Nil(t, res)
+		assert.True(t, errors.Is(err, ErrArgument), opts)
+		assert.Empty(t, buf.String())
+	}
+
+	agi, _ := mockAGI(respOk)
+	_, err := agi.RecordFileOpts(" ", RecordOptions{})
+	assert.True(t, errors.Is(err, ErrArgument))
+
+	agi, _ = mockAGI("200 result=-1 (writefile)")
+	res, err := agi.RecordFileOpts("msg", RecordOptions{})
+	assert.NotNil(t, res)
+	assert.True(t, errors.Is(err, ErrCommand))
+	assert.Contains(t, err.Error(), "writefile")
+
+	agi, _ = mockAGI("520 Invalid command syntax.")
+	res, err = agi.RecordFileOpts("msg", RecordOptions{})
+	assert.Nil(t, res)
+	assert.True(t, errors.Is(err, ErrCommand))
+}
+
+func TestFormatSampleRate(t *testing.T) {
+	tests := map[string]int{
+		"wav": 8000, "gsm": 8000, "sln": 8000, "wav16": 16000,
+		"sln16": 16000, "slin48": 48000, "sln44": 44100, "g722": 16000,
+		"siren14": 32000, "opus": 48000,
+	}
+	for format, rate := range tests {
+		assert.Equal(t, rate, formatSampleRate(format), format)
+	}
+}
@@ -0,0 +1,251 @@
+package goagi
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrMenu menu failed, for example, maximum retries reached without fallback
+	ErrMenu = newError("Menu")
+	// ErrMenuRepeat returned by menu action to play the menu again
+	ErrMenuRepeat = newError("Menu repeat")
+	// ErrMenuBack returned by submenu action to return to the parent menu
+	ErrMenuBack = newError("Menu back")
+)
+
+// MenuAction is executed when caller selects menu option.
+// Use Submenu of the Menu as action to run nested menu.
+type MenuAction func(agi *AGI) error
+
+// MenuEventType type of the menu event
+type MenuEventType int
+
+// Menu events
+const (
+	// MenuEnter menu prompt is about to play
+	MenuEnter MenuEventType = iota
+	// MenuSelect caller selected valid option
+	MenuSelect
+	// MenuInvalid caller pressed digit that is not an option
+	MenuInvalid
+	// MenuTimeout caller has not pressed any digit
+	MenuTimeout
+	// MenuFallback maximum retries reached
+	MenuFallback
+)
+
+// String returns menu event type name
+func (t MenuEventType) String() string {
+	switch t {
+	case MenuEnter:
+		return "enter"
+	case MenuSelect:
+		return "select"
+	case MenuInvalid:
+		return "invalid"
+	case MenuTimeout:
+		return "timeout"
+	case MenuFallback:
+		return "fallback"
+	}
+	return fmt.Sprintf("unknown(%d)", int(t))
+}
+
+// MenuEvent is passed to menu hook for analytics
+type MenuEvent struct {
+	// Menu name
+	Menu string
+	// Type of the event
+	Type MenuEventType
+	// Digit pressed by caller for select and invalid events
+	Digit string
+	// Attempt number starting from 1
+	Attempt int
+}
+
+/*
+Menu is declarative IVR menu.
+
+Example:
+
+	sales := &goagi.Menu{
+		Name:   "sales",
+		Prompt: "sales-menu",
+		Options: map[string]goagi.MenuAction{
+			"1": orderStatus,
+			"*": func(*goagi.AGI) error { return goagi.ErrMenuBack },
+		},
+	}
+	main := &goagi.Menu{
+		Name:          "main",
+		Prompt:        "main-menu",
+		Options:       map[string]goagi.MenuAction{"1": sales.Submenu(), "0": operator},
+		MaxRetries:    2,
+		InvalidPrompt: "option-is-invalid",
+		TimeoutPrompt: "are-you-still-there",
+		Fallback:      operator,
+	}
+	err := main.Run(agi)
+*/
+type Menu struct {
+	// Name of the menu used in events
+	Name string
+	// Prompt sound file played to the caller. Interrupted by any digit
+	Prompt string
+	// Options maps digit to action
+	Options map[string]MenuAction
+	// Timeout to wait for selection after prompt. Asterisk default if zero
+	Timeout time.Duration
+	// MaxRetries number of retries after invalid selection or timeout.
+	// Zero means menu is played once
+	MaxRetries int
+	// InvalidPrompt sound file played after invalid selection
+	InvalidPrompt string
+	// TimeoutPrompt sound file played when caller has not pressed any digit
+	TimeoutPrompt string
+	// Fallback action when maximum retries reached. If nil then Run returns ErrMenu
+	Fallback MenuAction
+	// OnEvent hook for analytics
+	OnEvent func(MenuEvent)
+}
+
+func (m *Menu) validate() error {
+	for digit, action := range m.Options {
+		if len(digit) != 1 || !IsDTMF(rune(digit[0])) {
+			return ErrArgument.Msg("menu %q option must be single digit: %q", m.Name, digit)
+		}
+		if action == nil {
+			return ErrArgument.Msg("menu %q option %q has no action", m.Name, digit)
+		}
+	}
+	return nil
+}
+
+func (m *Menu) event(typ MenuEventType, digit string, attempt int) {
+	if m.OnEvent != nil {
+		m.OnEvent(MenuEvent{Menu: m.Name, Type: typ, Digit: digit, Attempt: attempt})
+	}
+}
+
+// escape digits that interrupt menu prompt: all keypad and options digits
+func (m *Menu) escape() string {
+	keys := make([]string, 0, len(m.Options))
+	for digit := range m.Options {
+		keys = append(keys, digit)
+	}
+	sort.Strings(keys)
+	return string(AllDigits.Union(Digits(strings.Join(keys, ""))))
+}
+
+// prompt plays menu prompt and waits for digit. Returns empty string on timeout
+func (m *Menu) prompt(agi *AGI) (string, error) {
+	var resp Response
+	var err error
+	if m.Prompt == "" {
+		timeout := m.Timeout
+		if timeout == 0 {
+			timeout = DefaultFirstDigitTimeout
+		}
+		resp, err = agi.WaitForDigitTimeout(timeout)
+	} else {
+		resp, err = agi.GetOptionTimeout(m.Prompt, m.escape(), m.Timeout)
+	}
+	if err != nil {
+		return "", err
+	}
+	if resp.Code() == codeE511 || resp.Result() < 0 || agi.IsHungup() {
+		return "", ErrHangup.Msg("menu %q", m.Name)
+	}
+	if resp.Code() != codeSucc {
+		return "", ErrCommand.Msg("%d %s", resp.Code(), resp.Data())
+	}
+	if resp.Result() == 0 {
+		return "", nil
+	}
+	return string(rune(resp.Result())), nil
+}
+
+func (m *Menu) play(agi *AGI, file string) error {
+	if file == "" {
+		return nil
+	}
+	resp, err := checkResponse(agi.StreamFile(file, "", 0))
+	if err != nil {
+		return err
+	}
+	if resp.Result() < 0 || agi.IsHungup() {
+		return ErrHangup.Msg("menu %q", m.Name)
+	}
+	return nil
+}
+
+/*
+Run executes menu on the channel until caller selects an option.
+Returns error of the selected action.
+
+When action returns ErrMenuRepeat then menu is played again.
+When action returns ErrMenuBack then Run returns ErrMenuBack, so the
+parent menu started with Submenu is played again. Root menu has no
+parent and the handler receives ErrMenuBack. Returns ErrHangup if
+channel hangs up.
+*/
+func (m *Menu) Run(agi *AGI) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+
+	for attempt := 1; ; {
+		m.event(MenuEnter, "", attempt)
+		digit, err := m.prompt(agi)
+		if err != nil {
+			return err
+		}
+
+		if action, ok := m.Options[digit]; ok {
+			m.event(MenuSelect, digit, attempt)
+			err := action(agi)
+			if errors.Is(err, ErrMenuRepeat) {
+				attempt = 1
+				continue
+			}
+			return err
+		}
+
+		retry := m.InvalidPrompt
+		if digit == "" {
+			m.event(MenuTimeout, "", attempt)
+			retry = m.TimeoutPrompt
+		} else {
+			m.event(MenuInvalid, digit, attempt)
+		}
+
+		if attempt > m.MaxRetries {
+			m.event(MenuFallback, "", attempt)
+			if m.Fallback == nil {
+				return ErrMenu.Msg("%q reached maximum retries %d", m.Name, m.MaxRetries)
+			}
+			return m.Fallback(agi)
+		}
+		if err := m.play(agi, retry); err != nil {
+			return err
+		}
+		attempt++
+	}
+}
+
+// Submenu returns action that runs menu nested in parent menu. When
+// caller goes back from the submenu with ErrMenuBack, parent menu is
+// played again.
+func (m *Menu) Submenu() MenuAction {
+	return func(agi *AGI) error {
+		err := m.Run(agi)
+		if errors.Is(err, ErrMenuBack) {
+			return ErrMenuRepeat
+		}
+		return err
+	}
+}
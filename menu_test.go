@@ -0,0 +1,168 @@
+package goagi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMenuEventTypeString(t *testing.T) {
+	assert.Equal(t, "enter", MenuEnter.String())
+	assert.Equal(t, "select", MenuSelect.String())
+	assert.Equal(t, "invalid", MenuInvalid.String())
+	assert.Equal(t, "timeout", MenuTimeout.String())
+	assert.Equal(t, "fallback", MenuFallback.String())
+	assert.Equal(t, "unknown(7)", MenuEventType(7).String())
+}
+
+func TestMenuSelect(t *testing.T) {
+	selected := ""
+	events := make([]MenuEvent, 0)
+	menu := &Menu{
+		Name:   "main",
+		Prompt: "main-menu",
+		Options: map[string]MenuAction{
+			"1": func(*AGI) error { selected = "sales"; return nil },
+			"A": func(*AGI) error { selected = "support"; return nil },
+		},
+		OnEvent: func(e MenuEvent) { events = append(events, e) },
+	}
+	agi, buf := mockAGIScript("200 result=65 endpos=1000")
+	err := menu.Run(agi)
+	assert.Nil(t, err)
+	assert.Equal(t, "support", selected)
+	assert.Equal(t, "GET OPTION main-menu \"0123456789*#A\"\n", buf.String())
+	assert.Equal(t, []MenuEvent{
+		{"main", MenuEnter, "", 1},
+		{"main", MenuSelect, "A", 1},
+	}, events)
+}
+
+func TestMenuRetriesAndFallback(t *testing.T) {
+	events := make([]MenuEventType, 0)
+	fallback := false
+	menu := &Menu{
+		Name:          "main",
+		Prompt:        "main-menu",
+		Options:       map[string]MenuAction{"1": func(*AGI) error { return nil }},
+		MaxRetries:    2,
+		InvalidPrompt: "option-is-invalid",
+		TimeoutPrompt: "are-you-there",
+		Fallback:      func(*AGI) error { fallback = true; return nil },
+		OnEvent:       func(e MenuEvent) { events = append(events, e.Type) },
+	}
+	agi, buf := mockAGIScript(
+		"200 result=57 endpos=100", // 9 is invalid
+		"200 result=0 endpos=100",
+		"200 result=0 endpos=1000", // timeout
+		"200 result=0 endpos=100",
+		"200 result=0 endpos=1000", // timeout
+	)
+	err := menu.Run(agi)
+	assert.Nil(t, err)
+	assert.True(t, fallback)
+	assert.Equal(t, "GET OPTION main-menu \"0123456789*#\"\n"+
+		"STREAM FILE option-is-invalid \"\" 0\n"+
+		"GET OPTION main-menu \"0123456789*#\"\n"+
+		"STREAM FILE are-you-there \"\" 0\n"+
+		"GET OPTION main-menu \"0123456789*#\"\n", buf.String())
+	assert.Equal(t, []MenuEventType{
+		MenuEnter, MenuInvalid,
+		MenuEnter, MenuTimeout,
+		MenuEnter, MenuTimeout, MenuFallback,
+	}, events)
+
+	// no fallback
+	menu.Fallback = nil
+	menu.MaxRetries = 0
+	agi, _ = mockAGIScript("200 result=0 endpos=1000")
+	err = menu.Run(agi)
+	assert.True(t, errors.Is(err, ErrMenu))
+	assert.Contains(t, err.Error(), `"main" reached maximum retries 0`)
+}
+
+func TestMenuSubmenu(t *testing.T) {
+	orders := 0
+	sub := &Menu{
+		Name: "sales",
+		Options: map[string]MenuAction{
+			"1": func(*AGI) error { orders++; return ErrMenuRepeat },
+			"*": func(*AGI) error { return ErrMenuBack },
+		},
+	}
+	done := errors.New("done")
+	menu := &Menu{
+		Name:   "main",
+		Prompt: "main-menu",
+		Options: map[string]MenuAction{
+			"1": sub.Submenu(),
+			"0": func(*AGI) error { return done },
+		},
+	}
+	agi, buf := mockAGIScript(
+		"200 result=49 endpos=10", // main: 1 -> sales
+		"200 result=49",           // sales: 1 -> repeat sales
+		"200 result=42",           // sales: * -> back
+		"200 result=48 endpos=10", // main: 0
+	)
+	err := menu.Run(agi)
+	assert.Equal(t, done, err)
+	assert.Equal(t, 1, orders)
+	assert.Equal(t, "GET OPTION main-menu \"0123456789*#\"\n"+
+		"WAIT FOR DIGIT 5000\n"+
+		"WAIT FOR DIGIT 5000\n"+
+		"GET OPTION main-menu \"0123456789*#\"\n", buf.String())
+
+	// root menu returns ErrMenuBack to the handler
+	agi, _ = mockAGIScript("200 result=42")
+	err = sub.Run(agi)
+	assert.True(t, errors.Is(err, ErrMenuBack))
+	assert.False(t, errors.Is(err, ErrMenuRepeat))
+}
+
+func TestMenuFail(t *testing.T) {
+	action := func(*AGI) error { return nil }
+	invalid := []*Menu{
+		{Options: map[string]MenuAction{"12": action}},
+		{Options: map[string]MenuAction{"x": action}},
+		{Options: map[string]MenuAction{"1": nil}},
+	}
+	for _, menu := range invalid {
+		agi, buf := mockAGIScript(respOk)
+		err := menu.Run(agi)
+		assert.True(t, errors.Is(err, ErrArgument))
+		assert.Empty(t, buf.String())
+	}
+
+	menu := &Menu{
+		Name:          "main",
+		Prompt:        "main-menu",
+		Options:       map[string]MenuAction{"1": action},
+		MaxRetries:    1,
+		InvalidPrompt: "invalid",
+	}
+	tests := [][]string{
+		{"200 result=-1 endpos=0"},
+		{"HANGUP\n200 result=0 endpos=0"},
+		{"511 Command Not Permitted on a dead channel or intercept routine"},
+		{"200 result=50 endpos=0", "200 result=-1 endpos=0"},
+	}
+	for _, responses := range tests {
+		agi, _ := mockAGIScript(responses...)
+		err := menu.Run(agi)
+		assert.True(t, errors.Is(err, ErrHangup), responses)
+	}
+
+	agi, _ := mockAGIScript("510 Invalid or unknown command")
+	err := menu.Run(agi)
+	assert.True(t, errors.Is(err, ErrCommand))
+
+	agi, _ = mockAGIScript("200 result=50 endpos=0", "520 Invalid command syntax.")
+	err = menu.Run(agi)
+	assert.True(t, errors.Is(err, ErrCommand))
+
+	agi, _ = mockAGIScript()
+	err = menu.Run(agi)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,184 @@
+package goagi
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+// Recover recovers handler panic and returns it as ErrAGI.
+// Panic with stack trace is logged if log is set.
+func Recover(log Debugger) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, agi *AGI) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					if log != nil {
+						log.Printf("panic in AGI handler: %v\n%s", r, debug.Stack())
+					}
+					err = ErrAGI.Msg("handler panic: %v", r)
+				}
+			}()
+			return next.ServeAGI(ctx, agi)
+		})
+	}
+}
+
+// AutoAnswer answers channel before handler
+func AutoAnswer() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, agi *AGI) error {
+			resp, err := checkResponse(agi.Answer())
+			if err != nil {
+				return err
+			}
+			if resp.Result() != 0 {
+				return ErrCommand.Msg("failed to answer channel")
+			}
+			return next.ServeAGI(ctx, agi)
+		})
+	}
+}
+
+// AutoHangup makes Asterisk hang up the channel after duration with
+// SET AUTOHANGUP. Duration is rounded up to seconds.
+func AutoHangup(d time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, agi *AGI) error {
+			if _, err := checkResponse(agi.SetAutoHangup(durationSec(d))); err != nil {
+				return err
+			}
+			return next.ServeAGI(ctx, agi)
+		})
+	}
+}
+
+/*
+Timeout limits session duration with context deadline. When deadline is
+exceeded, pending read of the FastAGI connection is interrupted, so
+command in progress fails, and ErrAGI is returned. Read deadline is
+cleared when handler returns, so outer middleware can still send commands.
+*/
+func Timeout(d time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, agi *AGI) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			done := make(chan struct{})
+			interrupted := make(chan error, 1)
+			go func() {
+				select {
+				case <-ctx.Done():
+					if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+						interrupted <- agi.setReadDeadline(time.Now())
+					}
+				case <-done:
+				}
+				close(interrupted)
+			}()
+
+			err := next.ServeAGI(ctx, agi)
+			close(done)
+			if ierr, ok := <-interrupted; ok {
+				if ierr == nil {
+					ierr = agi.setReadDeadline(time.Time{})
+				}
+				if ierr != nil {
+					return ErrAGI.Msg("session timeout after %s: %s", d, ierr)
+				}
+			}
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return ErrAGI.Msg("session timeout after %s", d)
+			}
+			return err
+		})
+	}
+}
+
+// setReadDeadline sets read deadline of connection that supports deadlines
+func (agi *AGI) setReadDeadline(t time.Time) error {
+	if conn, ok := agi.reader.(deadliner); ok {
+		return conn.SetReadDeadline(t)
+	}
+	return nil
+}
+
+// AccessLog logs session script, channel, caller and unique ID at start
+// and duration with result at the end
+func AccessLog(log Debugger) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, agi *AGI) error {
+			start := time.Now()
+			log.Printf("AGI %q start: channel=%s callerid=%q uniqueid=%s",
+				agi.Script(), agi.Env("channel"), agi.CallerID(), agi.Env("uniqueid"))
+			err := next.ServeAGI(ctx, agi)
+			if err != nil {
+				log.Printf("AGI %q failed in %s: %s", agi.Script(), time.Since(start), err)
+			} else {
+				log.Printf("AGI %q done in %s", agi.Script(), time.Since(start))
+			}
+			return err
+		})
+	}
+}
+
+// SessionMetrics collects metrics of sessions
+type SessionMetrics interface {
+	SessionStart(script string)
+	SessionEnd(script string, duration time.Duration, err error)
+}
+
+// Metrics reports session start and end to metrics collector
+func Metrics(m SessionMetrics) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, agi *AGI) error {
+			script := agi.Script()
+			start := time.Now()
+			m.SessionStart(script)
+			err := next.ServeAGI(ctx, agi)
+			m.SessionEnd(script, time.Since(start), err)
+			return err
+		})
+	}
+}
+
+// SessionStats is SessionMetrics with counters, for example to publish
+// with expvar
+type SessionStats struct {
+	active   int64
+	total    int64
+	failed   int64
+	duration int64
+}
+
+// SessionStart counts started session
+func (s *SessionStats) SessionStart(string) {
+	atomic.AddInt64(&s.active, 1)
+	atomic.AddInt64(&s.total, 1)
+}
+
+// SessionEnd counts finished session
+func (s *SessionStats) SessionEnd(_ string, d time.Duration, err error) {
+	atomic.AddInt64(&s.active, -1)
+	atomic.AddInt64(&s.duration, int64(d))
+	if err != nil {
+		atomic.AddInt64(&s.failed, 1)
+	}
+}
+
+// Active returns number of sessions in progress
+func (s *SessionStats) Active() int64 { return atomic.LoadInt64(&s.active) }
+
+// Total returns number of started sessions
+func (s *SessionStats) Total() int64 { return atomic.LoadInt64(&s.total) }
+
+// Failed returns number of sessions finished with error
+func (s *SessionStats) Failed() int64 { return atomic.LoadInt64(&s.failed) }
+
+// Duration returns total duration of finished sessions
+func (s *SessionStats) Duration() time.Duration {
+	return time.Duration(atomic.LoadInt64(&s.duration))
+}
@@ -0,0 +1,149 @@
+package goagi
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecover(t *testing.T) {
+	log := &testLog{}
+	h := Chain(HandlerFunc(func(context.Context, *AGI) error {
+		panic("boom")
+	}), Recover(log))
+	err := h.ServeAGI(context.Background(), &AGI{})
+	assert.True(t, errors.Is(err, ErrAGI))
+	assert.Contains(t, err.Error(), "handler panic: boom")
+	assert.Contains(t, log.String(), "panic in AGI handler: boom")
+
+	h = Chain(HandlerFunc(func(context.Context, *AGI) error {
+		panic("boom")
+	}), Recover(nil))
+	assert.NotNil(t, h.ServeAGI(context.Background(), &AGI{}))
+}
+
+func TestAutoAnswer(t *testing.T) {
+	srv := NewServer()
+	called := false
+	srv.HandleFunc("ivr", func(ctx context.Context, agi *AGI) error {
+		called = true
+		return nil
+	}, AutoAnswer())
+	cmds := serveTest(srv, "ivr", "200 result=0")
+	assert.True(t, called)
+	assert.Equal(t, []string{"ANSWER"}, cmds)
+
+	called = false
+	log := &testLog{}
+	srv.ErrorLog = log
+	serveTest(srv, "ivr", "200 result=-1")
+	assert.False(t, called)
+	assert.Contains(t, log.String(), "failed to answer channel")
+}
+
+func TestAutoHangup(t *testing.T) {
+	srv := NewServer()
+	srv.HandleFunc("ivr", func(ctx context.Context, agi *AGI) error {
+		return nil
+	}, AutoHangup(90*time.Second))
+	cmds := serveTest(srv, "ivr")
+	assert.Equal(t, []string{"SET AUTOHANGUP 90"}, cmds)
+}
+
+func TestTimeout(t *testing.T) {
+	srv := NewServer()
+	log := &testLog{}
+	srv.ErrorLog = log
+	srv.HandleFunc("slow", func(ctx context.Context, agi *AGI) error {
+		// fake Asterisk never replies to the last command
+		_, err := agi.WaitForDigit(-1)
+		return err
+	}, Timeout(50*time.Millisecond))
+
+	client, server := net.Pipe()
+	go func() {
+		client.Write([]byte("agi_network_script: slow\n\n"))
+		io.Copy(io.Discard, client)
+	}()
+	start := time.Now()
+	srv.ServeConn(server)
+	client.Close()
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Contains(t, log.String(), "session timeout after 50ms")
+
+	// handler finished in time
+	srv.HandleFunc("fast", func(ctx context.Context, agi *AGI) error {
+		assert.NotNil(t, ctx.Done())
+		return nil
+	}, Timeout(time.Second))
+	log.lines = nil
+	serveTest(srv, "fast")
+	assert.Empty(t, log.String())
+}
+
+func TestTimeoutClearsDeadline(t *testing.T) {
+	srv := NewServer()
+	after := make(chan error, 1)
+	outer := func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, agi *AGI) error {
+			err := next.ServeAGI(ctx, agi)
+			_, verr := checkResponse(agi.Verbose("after timeout"))
+			after <- verr
+			return err
+		})
+	}
+	srv.HandleFunc("slow", func(ctx context.Context, agi *AGI) error {
+		_, err := agi.WaitForDigit(-1)
+		return err
+	}, outer, Timeout(20*time.Millisecond))
+
+	client, server := net.Pipe()
+	go func() {
+		defer client.Close()
+		client.Write([]byte("agi_network_script: slow\n\n"))
+		reader := bufio.NewReader(client)
+		// no reply to WAIT FOR DIGIT
+		if _, err := reader.ReadString('\n'); err != nil {
+			return
+		}
+		if _, err := reader.ReadString('\n'); err != nil {
+			return
+		}
+		client.Write([]byte("200 result=1\n"))
+	}()
+	srv.ServeConn(server)
+	assert.Nil(t, <-after)
+}
+
+func TestAccessLogMetrics(t *testing.T) {
+	log := &testLog{}
+	stats := &SessionStats{}
+	srv := NewServer()
+	srv.Use(AccessLog(log), Metrics(stats))
+	srv.HandleFunc("ok", func(context.Context, *AGI) error {
+		assert.Equal(t, int64(1), stats.Active())
+		return nil
+	})
+	srv.HandleFunc("fail", func(context.Context, *AGI) error {
+		return errors.New("oops")
+	})
+
+	serveTest(srv, "ok")
+	serveTest(srv, "fail")
+	assert.Equal(t, int64(0), stats.Active())
+	assert.Equal(t, int64(2), stats.Total())
+	assert.Equal(t, int64(1), stats.Failed())
+	assert.True(t, stats.Duration() > 0)
+
+	out := log.String()
+	assert.Contains(t, out, `AGI "ok" start: channel=PJSIP/100-00000001 callerid="\"Alice\" <100>" uniqueid=1700.1`)
+	assert.Contains(t, out, `AGI "ok" done in`)
+	assert.Contains(t, out, `AGI "fail" failed in`)
+	assert.Contains(t, out, ": oops")
+}
@@ -0,0 +1,172 @@
+package goagi
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maximum length of the silence/N sound files
+const maxSilenceFile = 10
+
+type playItem func(agi *AGI, escape string) (Response, error)
+
+/*
+Playlist is a sequence of sound files, numbers, digits, alpha, dates and
+silence played with single escape digits set.
+
+Example:
+
+	res, err := goagi.NewPlaylist(goagi.AllDigits).
+		File("vm-youhave").
+		Number(5).
+		File("vm-messages").
+		Play(agi)
+*/
+type Playlist struct {
+	items  []playItem
+	escape Digits
+	err    error
+}
+
+// PlaylistResult is result of the playlist playback
+type PlaylistResult struct {
+	MediaResult
+	// Item is an index of interrupted item or -1 when playlist was not interrupted
+	Item int
+}
+
+// NewPlaylist creates playlist that can be interrupted by escape digits
+func NewPlaylist(escape Digits) *Playlist {
+	return &Playlist{escape: escape}
+}
+
+func (p *Playlist) add(item playItem) *Playlist {
+	p.items = append(p.items, item)
+	return p
+}
+
+func (p *Playlist) noSpace(kind, s string) bool {
+	if s == "" || strings.ContainsAny(s, " \t\n") {
+		if p.err == nil {
+			p.err = ErrArgument.Msg("invalid playlist %s: %q", kind, s)
+		}
+		return false
+	}
+	return true
+}
+
+// Len returns number of items in the playlist
+func (p *Playlist) Len() int { return len(p.items) }
+
+// File adds sound file
+func (p *Playlist) File(name string) *Playlist {
+	if !p.noSpace("file", name) {
+		return p
+	}
+	return p.add(func(agi *AGI, escape string) (Response, error) {
+		return agi.StreamFile(name, escape, 0)
+	})
+}
+
+// Prompt adds prompt from catalog resolved with session language on play
+func (p *Playlist) Prompt(id string) *Playlist {
+	return p.add(func(agi *AGI, escape string) (Response, error) {
+		return agi.StreamPrompt(id, escape, 0)
+	})
+}
+
+// Number adds number said with SAY NUMBER
+func (p *Playlist) Number(n int) *Playlist {
+	return p.add(func(agi *AGI, escape string) (Response, error) {
+		return agi.SayNumber(strconv.Itoa(n), escape)
+	})
+}
+
+// Digits adds digits string said with SAY DIGITS
+func (p *Playlist) Digits(digits string) *Playlist {
+	if !p.noSpace("digits", digits) {
+		return p
+	}
+	return p.add(func(agi *AGI, escape string) (Response, error) {
+		return agi.SayDigits(digits, escape)
+	})
+}
+
+// Alpha adds character string said with SAY ALPHA
+func (p *Playlist) Alpha(str string) *Playlist {
+	if !p.noSpace("alpha", str) {
+		return p
+	}
+	return p.add(func(agi *AGI, escape string) (Response, error) {
+		return agi.SayAlpha(str, escape)
+	})
+}
+
+// Date adds date said with SAY DATE in Asterisk timezone
+func (p *Playlist) Date(t time.Time) *Playlist {
+	return p.add(func(agi *AGI, escape string) (Response, error) {
+		return agi.SayDateAt(t, escape, nil)
+	})
+}
+
+// Datetime adds time said with SAY DATETIME with format and timezone.
+// Nil location says time in Asterisk timezone.
+func (p *Playlist) Datetime(t time.Time, format string, loc *time.Location) *Playlist {
+	return p.add(func(agi *AGI, escape string) (Response, error) {
+		return agi.SayDatetimeAt(t, escape, format, loc)
+	})
+}
+
+// Silence adds silence played from Asterisk core silence/N sound files.
+// Duration is rounded up to seconds.
+func (p *Playlist) Silence(d time.Duration) *Playlist {
+	for sec := durationSec(d); sec > 0; sec -= maxSilenceFile {
+		n := sec
+		if n > maxSilenceFile {
+			n = maxSilenceFile
+		}
+		p.File("silence/" + strconv.Itoa(n))
+	}
+	return p
+}
+
+/*
+Play plays playlist items in order. Playback stops when caller presses one
+of the escape digits or channel hangs up. Returns which item was interrupted,
+digit and endpos.
+*/
+func (p *Playlist) Play(agi *AGI) (*PlaylistResult, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if err := p.escape.Validate(); err != nil {
+		return nil, err
+	}
+
+	res := &PlaylistResult{Item: -1}
+	for i, item := range p.items {
+		resp, err := item(agi, string(p.escape))
+		if err != nil {
+			return nil, err
+		}
+		res.Response = resp
+		res.EndPos = resp.EndPos()
+
+		if resp.Code() == codeE511 || resp.Result() < 0 || agi.IsHungup() {
+			res.Item = i
+			res.Reason = StopHangup
+			return res, nil
+		}
+		if resp.Code() != codeSucc {
+			return res, ErrCommand.Msg("%d %s", resp.Code(), resp.Data())
+		}
+		if resp.Result() > 0 {
+			res.Item = i
+			res.Reason = StopDTMF
+			res.Digit = string(rune(resp.Result()))
+			return res, nil
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,112 @@
+package goagi
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlaylistPlay(t *testing.T) {
+	tm := time.Unix(1563844045, 0).In(time.UTC)
+	list := NewPlaylist("#").
+		File("vm-youhave").
+		Number(12).
+		File("vm-messages").
+		Digits("123").
+		Alpha("abc").
+		Date(tm).
+		Datetime(tm, "HM", time.UTC).
+		Silence(1500 * time.Millisecond)
+	assert.Equal(t, 8, list.Len())
+
+	responses := make([]string, list.Len())
+	for i := range responses {
+		responses[i] = "200 result=0 endpos=100"
+	}
+	agi, buf := mockAGIScript(responses...)
+	res, err := list.Play(agi)
+	assert.Nil(t, err)
+	assert.Equal(t, -1, res.Item)
+	assert.Equal(t, StopFinished, res.Reason)
+	assert.Equal(t, "", res.Digit)
+	assert.Equal(t, "STREAM FILE vm-youhave \"#\" 0\n"+
+		"SAY NUMBER 12 \"#\"\n"+
+		"STREAM FILE vm-messages \"#\" 0\n"+
+		"SAY DIGITS 123 \"#\"\n"+
+		"SAY ALPHA abc \"#\"\n"+
+		"SAY DATE 1563844045 \"#\"\n"+
+		"SAY DATETIME 1563844045 \"#\" \"HM\" \"UTC\"\n"+
+		"STREAM FILE silence/2 \"#\" 0\n", buf.String())
+}
+
+func TestPlaylistInterrupt(t *testing.T) {
+	list := NewPlaylist(AllDigits).File("vm-youhave").Number(5).File("vm-messages")
+
+	agi, buf := mockAGIScript("200 result=0 endpos=100", "200 result=35")
+	res, err := list.Play(agi)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, res.Item)
+	assert.Equal(t, StopDTMF, res.Reason)
+	assert.Equal(t, "#", res.Digit)
+	assert.Equal(t, "STREAM FILE vm-youhave \"0123456789*#\" 0\n"+
+		"SAY NUMBER 5 \"0123456789*#\"\n", buf.String())
+
+	agi, _ = mockAGIScript("200 result=0 endpos=100", "200 result=0", "200 result=50 endpos=880")
+	res, err = list.Play(agi)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, res.Item)
+	assert.Equal(t, "2", res.Digit)
+	assert.EqualValues(t, 880, res.EndPos)
+
+	agi, buf = mockAGIScript("200 result=-1 endpos=10")
+	res, err = list.Play(agi)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, res.Item)
+	assert.Equal(t, StopHangup, res.Reason)
+	assert.Equal(t, "STREAM FILE vm-youhave \"0123456789*#\" 0\n", buf.String())
+
+	agi, _ = mockAGIScript("HANGUP\n200 result=0 endpos=10")
+	res, err = list.Play(agi)
+	assert.Nil(t, err)
+	assert.Equal(t, StopHangup, res.Reason)
+}
+
+func TestPlaylistSilence(t *testing.T) {
+	list := NewPlaylist(NoDigits).Silence(0).Silence(25 * time.Second)
+	assert.Equal(t, 3, list.Len())
+
+	agi, buf := mockAGIScript("200 result=0", "200 result=0", "200 result=0")
+	_, err := list.Play(agi)
+	assert.Nil(t, err)
+	assert.Equal(t, "STREAM FILE silence/10 \"\" 0\n"+
+		"STREAM FILE silence/10 \"\" 0\n"+
+		"STREAM FILE silence/5 \"\" 0\n", buf.String())
+}
+
+func TestPlaylistFail(t *testing.T) {
+	lists := []*Playlist{
+		NewPlaylist("1 2").File("foo"),
+		NewPlaylist("").File("foo bar").File("baz"),
+		NewPlaylist("").File(""),
+		NewPlaylist("").Digits("1 2"),
+		NewPlaylist("").Alpha("a b"),
+	}
+	for _, list := range lists {
+		agi, buf := mockAGIScript(respOk)
+		res, err := list.Play(agi)
+		assert.Nil(t, res)
+		assert.True(t, errors.Is(err, ErrArgument))
+		assert.Empty(t, buf.String())
+	}
+
+	agi, _ := mockAGIScript("510 Invalid or unknown command")
+	_, err := NewPlaylist("").File("foo").Play(agi)
+	assert.True(t, errors.Is(err, ErrCommand))
+
+	agi, _ = mockAGIScript()
+	res, err := NewPlaylist("").File("foo").Play(agi)
+	assert.Nil(t, res)
+	assert.NotNil(t, err)
+}
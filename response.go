@@ -55,27 +55,77 @@ func (r *responseSuccess) EndPos() int64 { return r.endpos }
 func (r *responseSuccess) Digit() string { return r.digit }
 func (r *responseSuccess) SResults() int { return r.sresults }
 
-func (agi *AGI) sessionSetup(data []string) {
+func (agi *AGI) sessionSetup(data []string) error {
 	agi.dbg("[>] sessionSetup")
 	agi.env = make(map[string]string)
 	agi.arg = make([]string, 0)
+	args := make(map[int]string)
+	maxArg := 0
+	maxLines, _ := agi.setupLimits()
+	invalid := make([]string, 0)
 
 	for _, line := range data {
-		idx := strings.Index(line, ": ")
-		if idx == -1 || line[:4] != "agi_" {
+		key, val, ok := parseSetupLine(line)
+		if !ok {
 			agi.dbg(" [!] ignore invalid line: %q", line)
+			invalid = append(invalid, line)
 			continue
 		}
-		if line[:8] == "agi_arg_" {
-			arg := line[idx+2:]
-			agi.arg = append(agi.arg, arg)
-			agi.dbg(" [v] add arg: %q", arg)
+		if !matchPrefix(key, "arg_") {
+			agi.dbg(" [v] add env: %s => %s", key, val)
+			agi.env[key] = val
 			continue
 		}
-		key, val := line[4:idx], line[idx+2:]
-		agi.dbg(" [v] add env: %s => %s", key, val)
-		agi.env[key] = val
+		num, err := strconv.Atoi(key[4:])
+		if err != nil || num < 1 || num > maxLines {
+			agi.dbg(" [!] ignore invalid argument: %q", line)
+			invalid = append(invalid, line)
+			continue
+		}
+		agi.dbg(" [v] add arg %d: %q", num, val)
+		args[num] = val
+		if num > maxArg {
+			maxArg = num
+		}
+	}
+
+	// arguments are ordered by index and gaps are filled with empty strings
+	for i := 1; i <= maxArg; i++ {
+		agi.arg = append(agi.arg, args[i])
 	}
+
+	if len(invalid) > 0 {
+		return ErrSetup.Msg("%d invalid line(s): %q", len(invalid), invalid)
+	}
+	return nil
+}
+
+// parseSetupLine splits setup line "agi_name: value" to name without
+// prefix and value. Returns false if line is malformed.
+func parseSetupLine(line string) (string, string, bool) {
+	if !matchPrefix(line, "agi_") {
+		return "", "", false
+	}
+	idx := strings.IndexByte(line, ':')
+	if idx < 5 {
+		return "", "", false
+	}
+	key, val := line[4:idx], line[idx+1:]
+	if strings.ContainsAny(key, " \t") {
+		return "", "", false
+	}
+	return key, strings.TrimPrefix(val, " "), true
+}
+
+// checkResponse returns ErrCommand when response code is not 200
+func checkResponse(resp Response, err error) (Response, error) {
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code() != codeSucc {
+		return resp, ErrCommand.Msg("%d %s", resp.Code(), resp.Data())
+	}
+	return resp, nil
 }
 
 // read and parse response
@@ -162,11 +212,14 @@ func parseEndpos(tokens []string) int64 {
 	return 0
 }
 
+// parseValue splits "(value) key=val" to value in parentheses and the rest.
+// Value is closed by the last parenthesis, as variables and functions
+// may return values with parentheses.
 func parseValue(data string) (string, string) {
 	if data[0] != '(' {
 		return data, ""
 	}
-	if idx := strings.IndexByte(data, ')'); idx > 0 {
+	if idx := strings.LastIndexByte(data, ')'); idx > 0 {
 		return data[idx+1:], data[1:idx]
 	}
 	return data, ""
@@ -30,6 +30,39 @@ func TestSessionSetup(t *testing.T) {
 	assert.Equal(t, 6, len(agi.env))
 }
 
+func TestSessionSetupMalformed(t *testing.T) {
+	agi := &AGI{}
+	input := []string{
+		"", "a", "agi_", "agi_:", "agi_: foo", "agi", "agi_foo bar: x",
+		"agi_arg_: foo", "agi_arg_x: foo", "agi_arg_0: foo", "agi_arg_-1: foo",
+		"agi_arg_999: foo", "agi_channel: SIP/2222", "agi_accountcode: ",
+		"agi_rdnis:unknown",
+	}
+	err := agi.sessionSetup(input)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "12 invalid line(s)")
+	assert.Contains(t, err.Error(), `"agi_arg_999: foo"`)
+	assert.Equal(t, 3, len(agi.env))
+	assert.Equal(t, "SIP/2222", agi.env["channel"])
+	assert.Equal(t, "", agi.env["accountcode"])
+	assert.Equal(t, "unknown", agi.env["rdnis"])
+	assert.Empty(t, agi.arg)
+}
+
+func TestSessionSetupArgsOrder(t *testing.T) {
+	agi := &AGI{}
+	input := []string{
+		"agi_arg_3: third",
+		"agi_arg_1: first",
+		"agi_channel: SIP/2222",
+		"agi_arg_5: fifth",
+	}
+	err := agi.sessionSetup(input)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"first", "", "third", "", "fifth"}, agi.arg)
+	assert.Equal(t, 1, len(agi.env))
+}
+
 func TestParseResponse(t *testing.T) {
 	tests := []struct {
 		input  string
@@ -45,6 +78,7 @@ func TestParseResponse(t *testing.T) {
 		{"100 result=0\n", 100, 0, "", "", 0, "", 0},
 		{"100 Trying\n", 100, 0, "Trying", "Trying", 0, "", 0},
 		{"200 result=1\n", 200, 1, "", "", 0, "", 0},
+		{"200 result=1 (Alice (work))\n", 200, 1, "Alice (work)", "", 0, "", 0},
 		{"200 result=\n", 200, 0, "", "", 0, "", 0},
 
 		{"200 result=1 (hangup)\n", 200, 1, "hangup", "", 0, "", 0},
@@ -0,0 +1,270 @@
+package goagi
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Currency sound files of money units. Forms are ordered by plural rule
+// of the language.
+type Currency struct {
+	// Major unit forms, for example "dollar", "dollars"
+	Major []string
+	// Minor unit forms, for example "cent", "cents". One major unit is
+	// 100 minor units
+	Minor []string
+}
+
+/*
+SayRules are language rules for SayMoney, SayOrdinal and SayDuration.
+Asterisk looks up sound files in the channel language directory, so the same
+file names are used for different languages. Default rules use core sound
+files where they exist. Core sounds have no French ordinals, so French rules
+have no Ordinal and it must be set with Catalog.SetSayRules to say them.
+German ordinals use digits/h-* files of German core sounds.
+*/
+type SayRules struct {
+	// Plural rule to select unit forms
+	Plural PluralRule
+	// And sound file between major and minor money units
+	And string
+	// Minus sound file for negative amounts
+	Minus string
+	// Hour, Minute and Second unit forms
+	Hour, Minute, Second []string
+	// Ordinal adds ordinal number to the playlist. Nil if not supported
+	Ordinal func(list *Playlist, n int64)
+	// Currencies by ISO 4217 code
+	Currencies map[string]Currency
+}
+
+func defaultCurrencies() map[string]Currency {
+	cents := []string{"cent", "cents"}
+	return map[string]Currency{
+		"USD": {Major: []string{"letters/dollar", "dollars"}, Minor: cents},
+		"CAD": {Major: []string{"letters/dollar", "dollars"}, Minor: cents},
+		"EUR": {Major: []string{"euro", "euros"}, Minor: cents},
+	}
+}
+
+// DefaultSayRules returns built-in rules for the language. English, French
+// and German are supported, other languages get English rules.
+func DefaultSayRules(lang string) *SayRules {
+	rules := &SayRules{
+		Plural:     PluralEnglish,
+		And:        "and",
+		Minus:      "digits/minus",
+		Hour:       []string{"hour", "hours"},
+		Minute:     []string{"minute", "minutes"},
+		Second:     []string{"second", "seconds"},
+		Ordinal:    ordinalEnglish,
+		Currencies: defaultCurrencies(),
+	}
+	switch baseLanguage(lang) {
+	case "fr":
+		rules.Plural = PluralFrench
+		rules.And = "et"
+		rules.Ordinal = nil
+	case "de":
+		rules.And = "und"
+		rules.Ordinal = ordinalGerman
+	}
+	return rules
+}
+
+func baseLanguage(lang string) string {
+	if idx := strings.IndexAny(lang, "_-"); idx > 0 {
+		return lang[:idx]
+	}
+	return lang
+}
+
+// ordinalEnglish says ordinal with digits/h-N files like Asterisk enumeration:
+// "twenty" + "first", "two hundred" + "third", "thousandth"
+func ordinalEnglish(list *Playlist, n int64) {
+	if n < 0 {
+		list.File("digits/minus")
+		n = -n
+	}
+	for _, unit := range []struct {
+		value int64
+		file  string
+	}{{1000000, "digits/h-million"}, {1000, "digits/h-thousand"}, {100, "digits/h-hundred"}} {
+		if n < unit.value {
+			continue
+		}
+		if n%unit.value == 0 {
+			list.Number(int(n / unit.value))
+			list.File(unit.file)
+			return
+		}
+		list.Number(int(n - n%unit.value))
+		n %= unit.value
+	}
+	switch {
+	case n <= 20:
+		list.File("digits/h-" + strconv.FormatInt(n, 10))
+	case n%10 == 0:
+		list.File("digits/h-" + strconv.FormatInt(n, 10))
+	default:
+		list.File("digits/" + strconv.FormatInt(n-n%10, 10))
+		list.File("digits/h-" + strconv.FormatInt(n%10, 10))
+	}
+}
+
+// ordinalGerman says ordinal with German digits/h-N files like Asterisk
+// enumeration: "ein" + "und" + "zwanzigste", "zwei" + "hundertste"
+func ordinalGerman(list *Playlist, n int64) {
+	if n < 0 {
+		list.File("digits/minus")
+		n = -n
+	}
+	for _, unit := range []struct {
+		value int64
+		file  string
+	}{{1000000, "digits/h-million"}, {1000, "digits/h-tausend"}, {100, "digits/h-hundert"}} {
+		if n < unit.value {
+			continue
+		}
+		if n%unit.value == 0 {
+			if n == unit.value {
+				list.File("digits/ein")
+			} else {
+				list.Number(int(n / unit.value))
+			}
+			list.File(unit.file)
+			return
+		}
+		list.Number(int(n - n%unit.value))
+		n %= unit.value
+	}
+	if n < 20 || n%10 == 0 {
+		list.File("digits/h-" + strconv.FormatInt(n, 10))
+		return
+	}
+	if n%10 == 1 {
+		list.File("digits/ein")
+	} else {
+		list.Number(int(n % 10))
+	}
+	list.File("digits/und")
+	list.File("digits/h-" + strconv.FormatInt(n-n%10, 10))
+}
+
+func (r *SayRules) form(forms []string, n int64) string {
+	idx := r.Plural(n)
+	if idx >= len(forms) {
+		idx = len(forms) - 1
+	}
+	return forms[idx]
+}
+
+// AddMoney adds money amount in minor units (cents) to the playlist
+func (r *SayRules) AddMoney(list *Playlist, cents int64, currency string) error {
+	cur, ok := r.Currencies[strings.ToUpper(currency)]
+	if !ok || len(cur.Major) == 0 || len(cur.Minor) == 0 {
+		return ErrArgument.Msg("unknown currency %q", currency)
+	}
+	if cents < 0 {
+		list.File(r.Minus)
+		cents = -cents
+	}
+	major, minor := cents/100, cents%100
+	if major > 0 || minor == 0 {
+		list.Number(int(major))
+		list.File(r.form(cur.Major, major))
+	}
+	if minor > 0 {
+		if major > 0 {
+			list.File(r.And)
+		}
+		list.Number(int(minor))
+		list.File(r.form(cur.Minor, minor))
+	}
+	return nil
+}
+
+// AddOrdinal adds ordinal number to the playlist. Zero has no ordinal form.
+func (r *SayRules) AddOrdinal(list *Playlist, n int64) error {
+	if r.Ordinal == nil {
+		return ErrArgument.Msg("ordinal numbers are not supported")
+	}
+	if n == 0 {
+		return ErrArgument.Msg("zero ordinal number")
+	}
+	r.Ordinal(list, n)
+	return nil
+}
+
+// AddDuration adds duration as hours, minutes and seconds to the playlist.
+// Zero units are skipped and duration is truncated to seconds.
+func (r *SayRules) AddDuration(list *Playlist, d time.Duration) {
+	if d < 0 {
+		list.File(r.Minus)
+		d = -d
+	}
+	sec := int64(d / time.Second)
+	parts := []struct {
+		n     int64
+		forms []string
+	}{{sec / 3600, r.Hour}, {sec % 3600 / 60, r.Minute}, {sec % 60, r.Second}}
+	said := false
+	for i, p := range parts {
+		if p.n == 0 && (said || i < len(parts)-1) {
+			continue
+		}
+		list.Number(int(p.n))
+		list.File(r.form(p.forms, p.n))
+		said = true
+	}
+}
+
+// SetSayRules overrides say rules of the language
+func (c *Catalog) SetSayRules(lang string, rules *SayRules) *Catalog {
+	if c.sayRules == nil {
+		c.sayRules = make(map[string]*SayRules)
+	}
+	c.sayRules[lang] = rules
+	return c
+}
+
+// SayRules returns say rules for the session language. Rules set in catalog
+// are used first, then built-in rules.
+func (agi *AGI) SayRules() *SayRules {
+	lang := agi.Language()
+	if agi.catalog != nil {
+		for _, l := range []string{lang, baseLanguage(lang)} {
+			if rules, ok := agi.catalog.sayRules[l]; ok {
+				return rules
+			}
+		}
+	}
+	return DefaultSayRules(lang)
+}
+
+// SayMoney says money amount in minor units (cents) of the currency,
+// for example "12 dollars and 5 cents" for 1205 USD
+func (agi *AGI) SayMoney(cents int64, currency string, escape Digits) (*PlaylistResult, error) {
+	list := NewPlaylist(escape)
+	if err := agi.SayRules().AddMoney(list, cents, currency); err != nil {
+		return nil, err
+	}
+	return list.Play(agi)
+}
+
+// SayOrdinal says ordinal number, for example "third" for 3
+func (agi *AGI) SayOrdinal(n int64, escape Digits) (*PlaylistResult, error) {
+	list := NewPlaylist(escape)
+	if err := agi.SayRules().AddOrdinal(list, n); err != nil {
+		return nil, err
+	}
+	return list.Play(agi)
+}
+
+// SayDuration says duration, for example "2 hours 5 minutes"
+func (agi *AGI) SayDuration(d time.Duration, escape Digits) (*PlaylistResult, error) {
+	list := NewPlaylist(escape)
+	agi.SayRules().AddDuration(list, d)
+	return list.Play(agi)
+}
@@ -0,0 +1,175 @@
+package goagi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// playlistCommands plays list and returns commands sent to Asterisk
+func playlistCommands(t *testing.T, list *Playlist) string {
+	t.Helper()
+	responses := make([]string, list.Len())
+	for i := range responses {
+		responses[i] = "200 result=0"
+	}
+	agi, buf := mockAGIScript(responses...)
+	_, err := list.Play(agi)
+	assert.Nil(t, err)
+	return buf.String()
+}
+
+// sayCommands converts short notation "n:12 f:dollars" to commands
+func sayCommands(short string) string {
+	var b strings.Builder
+	for _, tok := range strings.Fields(short) {
+		if tok[:2] == "n:" {
+			b.WriteString("SAY NUMBER " + tok[2:] + " \"\"\n")
+		} else {
+			b.WriteString("STREAM FILE " + tok[2:] + " \"\" 0\n")
+		}
+	}
+	return b.String()
+}
+
+func TestSayRulesMoney(t *testing.T) {
+	tests := []struct {
+		lang     string
+		cents    int64
+		currency string
+		cmds     string
+	}{
+		{"en", 1205, "USD", "n:12 f:dollars f:and n:5 f:cents"},
+		{"en", 100, "usd", "n:1 f:letters/dollar"},
+		{"en", 1, "CAD", "n:1 f:cent"},
+		{"en", 0, "EUR", "n:0 f:euros"},
+		{"en", -250, "EUR", "f:digits/minus n:2 f:euros f:and n:50 f:cents"},
+		{"fr", 0, "EUR", "n:0 f:euro"},
+		{"fr_CA", 101, "CAD", "n:1 f:letters/dollar f:et n:1 f:cent"},
+		{"fr", 20002, "EUR", "n:200 f:euros f:et n:2 f:cents"},
+	}
+	for _, tc := range tests {
+		list := NewPlaylist("")
+		err := DefaultSayRules(tc.lang).AddMoney(list, tc.cents, tc.currency)
+		assert.Nil(t, err)
+		assert.Equal(t, sayCommands(tc.cmds), playlistCommands(t, list), tc)
+	}
+
+	err := DefaultSayRules("en").AddMoney(NewPlaylist(""), 100, "XYZ")
+	assert.True(t, errors.Is(err, ErrArgument))
+	assert.Contains(t, err.Error(), `unknown currency "XYZ"`)
+}
+
+func TestSayRulesOrdinal(t *testing.T) {
+	tests := []struct {
+		lang string
+		n    int64
+		cmds string
+	}{
+		{"en", 1, "f:digits/h-1"},
+		{"en", 3, "f:digits/h-3"},
+		{"en", 20, "f:digits/h-20"},
+		{"en", 21, "f:digits/20 f:digits/h-1"},
+		{"en", 40, "f:digits/h-40"},
+		{"en", 100, "n:1 f:digits/h-hundred"},
+		{"en", 123, "n:100 f:digits/20 f:digits/h-3"},
+		{"en", 2000, "n:2 f:digits/h-thousand"},
+		{"en", 3000005, "n:3000000 f:digits/h-5"},
+		{"en", -2, "f:digits/minus f:digits/h-2"},
+		{"de", 3, "f:digits/h-3"},
+		{"de", 19, "f:digits/h-19"},
+		{"de", 30, "f:digits/h-30"},
+		{"de", 21, "f:digits/ein f:digits/und f:digits/h-20"},
+		{"de", 45, "n:5 f:digits/und f:digits/h-40"},
+		{"de", 100, "f:digits/ein f:digits/h-hundert"},
+		{"de", 203, "n:200 f:digits/h-3"},
+		{"de", 3000, "n:3 f:digits/h-tausend"},
+		{"de_AT", -1, "f:digits/minus f:digits/h-1"},
+	}
+	for _, tc := range tests {
+		list := NewPlaylist("")
+		assert.Nil(t, DefaultSayRules(tc.lang).AddOrdinal(list, tc.n), tc)
+		assert.Equal(t, sayCommands(tc.cmds), playlistCommands(t, list), tc)
+	}
+
+	err := DefaultSayRules("en").AddOrdinal(NewPlaylist(""), 0)
+	assert.True(t, errors.Is(err, ErrArgument))
+	assert.Contains(t, err.Error(), "zero ordinal")
+
+	err = DefaultSayRules("fr_CA").AddOrdinal(NewPlaylist(""), 3)
+	assert.True(t, errors.Is(err, ErrArgument))
+	assert.Contains(t, err.Error(), "not supported")
+}
+
+func TestSayRulesDuration(t *testing.T) {
+	tests := []struct {
+		lang string
+		d    time.Duration
+		cmds string
+	}{
+		{"en", 0, "n:0 f:seconds"},
+		{"en", time.Second, "n:1 f:second"},
+		{"en", 2*time.Hour + 5*time.Minute, "n:2 f:hours n:5 f:minutes"},
+		{"en", time.Hour + time.Minute + time.Second, "n:1 f:hour n:1 f:minute n:1 f:second"},
+		{"en", 90*time.Second + 500*time.Millisecond, "n:1 f:minute n:30 f:seconds"},
+		{"en", -time.Minute, "f:digits/minus n:1 f:minute"},
+		{"fr", 0, "n:0 f:second"},
+	}
+	for _, tc := range tests {
+		list := NewPlaylist("")
+		DefaultSayRules(tc.lang).AddDuration(list, tc.d)
+		assert.Equal(t, sayCommands(tc.cmds), playlistCommands(t, list), tc)
+	}
+}
+
+func TestAGISayHelpers(t *testing.T) {
+	agi, buf := mockAGIScript("200 result=0", "200 result=0", "200 result=0", "200 result=35")
+	agi.SetLanguage("en")
+	res, err := agi.SayMoney(1205, "USD", "#")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, res.Item)
+	assert.Equal(t, "#", res.Digit)
+	assert.Equal(t, "SAY NUMBER 12 \"#\"\nSTREAM FILE dollars \"#\" 0\n"+
+		"STREAM FILE and \"#\" 0\nSAY NUMBER 5 \"#\"\n", buf.String())
+
+	agi, buf = mockAGIScript("200 result=0")
+	res, err = agi.SayOrdinal(3, NoDigits)
+	assert.Nil(t, err)
+	assert.Equal(t, StopFinished, res.Reason)
+	assert.Equal(t, "STREAM FILE digits/h-3 \"\" 0\n", buf.String())
+
+	agi, buf = mockAGIScript("200 result=0", "200 result=0")
+	_, err = agi.SayDuration(5*time.Minute, NoDigits)
+	assert.Nil(t, err)
+	assert.Equal(t, "SAY NUMBER 5 \"\"\nSTREAM FILE minutes \"\" 0\n", buf.String())
+
+	agi, buf = mockAGIScript()
+	_, err = agi.SayMoney(1, "XYZ", NoDigits)
+	assert.True(t, errors.Is(err, ErrArgument))
+	assert.Empty(t, buf.String())
+
+	agi, buf = mockAGIScript()
+	_, err = agi.SayOrdinal(0, NoDigits)
+	assert.True(t, errors.Is(err, ErrArgument))
+	assert.Empty(t, buf.String())
+}
+
+func TestAGISayRules(t *testing.T) {
+	agi, _ := mockAGI(respOk)
+	agi.SetLanguage("fr_CA")
+	assert.Equal(t, "et", agi.SayRules().And)
+
+	agi.SetLanguage("de_DE")
+	assert.Equal(t, "und", agi.SayRules().And)
+
+	custom := DefaultSayRules("it")
+	custom.And = "e"
+	agi.catalog = NewCatalog().SetSayRules("it", custom)
+	agi.SetLanguage("it_IT")
+	assert.Equal(t, "e", agi.SayRules().And)
+	agi.SetLanguage("ru")
+	assert.Equal(t, "and", agi.SayRules().And)
+}
@@ -0,0 +1,221 @@
+package goagi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultServerSetupTimeout is default time to read FastAGI session setup
+const DefaultServerSetupTimeout = 10 * time.Second
+
+// ErrServerClosed returned by Serve after Close
+var ErrServerClosed = newError("FastAGI server closed")
+
+// Handler serves FastAGI session
+type Handler interface {
+	ServeAGI(ctx context.Context, agi *AGI) error
+}
+
+// HandlerFunc adapts function to Handler
+type HandlerFunc func(ctx context.Context, agi *AGI) error
+
+// ServeAGI calls f(ctx, agi)
+func (f HandlerFunc) ServeAGI(ctx context.Context, agi *AGI) error {
+	return f(ctx, agi)
+}
+
+// Middleware wraps Handler with cross-cutting behavior
+type Middleware func(Handler) Handler
+
+// Chain wraps handler with middleware. The first middleware is the
+// outermost and runs first.
+func Chain(h Handler, mw ...Middleware) Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+/*
+Server is FastAGI server that routes sessions to handlers by script
+path of agi://host/path URL. Middleware added with Use wraps all routes,
+middleware passed to Handle wraps the route only.
+
+	srv := goagi.NewServer()
+	srv.Use(goagi.Recover(logger), goagi.AccessLog(logger))
+	srv.Handle("ivr/main", mainMenu, goagi.AutoAnswer())
+	srv.HandleFunc("billing", billing, goagi.Timeout(time.Minute))
+	log.Fatal(srv.ListenAndServe(":4573"))
+*/
+type Server struct {
+	// NotFound handles sessions with unknown script, session is closed
+	// when not set
+	NotFound Handler
+	// Options of AGI sessions
+	Options []Option
+	// SetupTimeout limits time to read session setup, so peer that
+	// connects and sends nothing does not hold the session.
+	// DefaultServerSetupTimeout by NewServer, zero disables the limit
+	SetupTimeout time.Duration
+	// Debugger passed to AGI sessions
+	Debugger Debugger
+	// ErrorLog logs session errors if set
+	ErrorLog Debugger
+
+	mu         sync.Mutex
+	routes     map[string]Handler
+	middleware []Middleware
+	listeners  map[net.Listener]struct{}
+	ctx        context.Context
+	cancel     context.CancelFunc
+	closed     bool
+}
+
+// NewServer creates FastAGI server
+func NewServer() *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		SetupTimeout: DefaultServerSetupTimeout,
+		routes:       make(map[string]Handler),
+		listeners:    make(map[net.Listener]struct{}),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Use adds middleware applied to all routes
+func (s *Server) Use(mw ...Middleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.middleware = append(s.middleware, mw...)
+}
+
+// Handle registers handler for script path with route middleware.
+// Leading and trailing slashes of path are ignored.
+func (s *Server) Handle(path string, h Handler, mw ...Middleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes[strings.Trim(path, "/")] = Chain(h, mw...)
+}
+
+// HandleFunc registers handler function for script path
+func (s *Server) HandleFunc(path string, f HandlerFunc, mw ...Middleware) {
+	s.Handle(path, f, mw...)
+}
+
+// ListenAndServe listens on TCP address and serves sessions
+func (s *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// Serve accepts connections and serves each session in goroutine.
+// Returns ErrServerClosed after Close.
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		ln.Close()
+		return ErrServerClosed.Msg("%s", ln.Addr())
+	}
+	s.listeners[ln] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, ln)
+		s.mu.Unlock()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed.Msg("%s", ln.Addr())
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return err
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+// ServeConn serves single FastAGI session and closes connection
+func (s *Server) ServeConn(conn net.Conn) {
+	defer conn.Close()
+	opts := append([]Option{WithSetupTimeout(s.SetupTimeout)}, s.Options...)
+	agi, err := New(conn, conn, s.Debugger, opts...)
+	if err != nil {
+		s.logError("session setup from %s: %s", conn.RemoteAddr(), err)
+		return
+	}
+	defer agi.Close()
+
+	script := agi.Script()
+	h := s.handler(script)
+	if h == nil {
+		s.logError("no handler for script %q", script)
+		return
+	}
+	if err := h.ServeAGI(s.ctx, agi); err != nil {
+		s.logError("script %q: %s", script, err)
+	}
+}
+
+// handler returns route handler wrapped with global middleware
+func (s *Server) handler(script string) Handler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.routes[script]
+	if !ok {
+		h = s.NotFound
+	}
+	if h == nil {
+		return nil
+	}
+	return Chain(h, s.middleware...)
+}
+
+// Close stops listeners and cancels context of active sessions
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.cancel()
+	var err error
+	for ln := range s.listeners {
+		if cerr := ln.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func (s *Server) logError(pattern string, args ...interface{}) {
+	if s.ErrorLog != nil {
+		s.ErrorLog.Printf(pattern, args...)
+	}
+}
+
+// Script returns FastAGI script path from agi_network_script without
+// query and slashes: "ivr/main" for agi://host/ivr/main?lang=en
+func (agi *AGI) Script() string {
+	script := agi.Env("network_script")
+	if idx := strings.IndexByte(script, '?'); idx >= 0 {
+		script = script[:idx]
+	}
+	return strings.Trim(script, "/")
+}
@@ -0,0 +1,245 @@
+package goagi
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAsterisk sends session setup with script to conn and replies to
+// commands with responses in order. Returns received commands when
+// connection is closed.
+func fakeAsterisk(conn net.Conn, script string, responses ...string) <-chan []string {
+	ch := make(chan []string, 1)
+	go func() {
+		defer conn.Close()
+		setup := "agi_network: yes\n" +
+			"agi_network_script: " + script + "\n" +
+			"agi_channel: PJSIP/100-00000001\n" +
+			"agi_uniqueid: 1700.1\n" +
+			"agi_callerid: 100\n" +
+			"agi_calleridname: Alice\n\n"
+		var cmds []string
+		if _, err := conn.Write([]byte(setup)); err != nil {
+			ch <- cmds
+			return
+		}
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				ch <- cmds
+				return
+			}
+			cmds = append(cmds, strings.TrimSpace(line))
+			resp := "200 result=0"
+			if len(responses) > 0 {
+				resp, responses = responses[0], responses[1:]
+			}
+			if _, err := conn.Write([]byte(resp + "\n")); err != nil {
+				ch <- cmds
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// testLog collects log lines
+type testLog struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *testLog) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func (l *testLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.lines, "\n")
+}
+
+// serveTest serves one session of srv with fake Asterisk
+func serveTest(srv *Server, script string, responses ...string) []string {
+	client, server := net.Pipe()
+	cmds := fakeAsterisk(client, script, responses...)
+	srv.ServeConn(server)
+	return <-cmds
+}
+
+func TestChain(t *testing.T) {
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return HandlerFunc(func(ctx context.Context, agi *AGI) error {
+				order = append(order, name)
+				return next.ServeAGI(ctx, agi)
+			})
+		}
+	}
+	h := Chain(HandlerFunc(func(context.Context, *AGI) error {
+		order = append(order, "handler")
+		return nil
+	}), mw("first"), mw("second"))
+	assert.Nil(t, h.ServeAGI(context.Background(), &AGI{}))
+	assert.Equal(t, []string{"first", "second", "handler"}, order)
+}
+
+func TestScript(t *testing.T) {
+	tests := map[string]string{
+		"ivr/main":          "ivr/main",
+		"/ivr/main/":        "ivr/main",
+		"billing?lang=en&x": "billing",
+		"":                  "",
+	}
+	for in, want := range tests {
+		agi := &AGI{env: map[string]string{"network_script": in}}
+		assert.Equal(t, want, agi.Script(), in)
+	}
+}
+
+func TestServerRoutes(t *testing.T) {
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return HandlerFunc(func(ctx context.Context, agi *AGI) error {
+				order = append(order, name)
+				return next.ServeAGI(ctx, agi)
+			})
+		}
+	}
+	srv := NewServer()
+	srv.HandleFunc("/ivr/main", func(ctx context.Context, agi *AGI) error {
+		order = append(order, "main")
+		_, err := agi.Verbose("main")
+		return err
+	}, mw("route"))
+	srv.HandleFunc("billing", func(ctx context.Context, agi *AGI) error {
+		order = append(order, "billing")
+		return nil
+	})
+	srv.Use(mw("global"))
+
+	cmds := serveTest(srv, "ivr/main?lang=en")
+	assert.Equal(t, []string{"global", "route", "main"}, order)
+	assert.Equal(t, []string{`VERBOSE "main" 1`}, cmds)
+
+	order = nil
+	serveTest(srv, "billing")
+	assert.Equal(t, []string{"global", "billing"}, order)
+
+	// unknown script without NotFound handler
+	order = nil
+	log := &testLog{}
+	srv.ErrorLog = log
+	assert.Empty(t, serveTest(srv, "unknown"))
+	assert.Empty(t, order)
+	assert.Contains(t, log.String(), `no handler for script "unknown"`)
+
+	srv.NotFound = HandlerFunc(func(ctx context.Context, agi *AGI) error {
+		order = append(order, "notfound")
+		return errors.New("not found")
+	})
+	serveTest(srv, "unknown")
+	assert.Equal(t, []string{"global", "notfound"}, order)
+	assert.Contains(t, log.String(), `script "unknown": not found`)
+}
+
+func TestServerConcurrentErrors(t *testing.T) {
+	var started sync.WaitGroup
+	started.Add(2)
+	errs := make(chan error, 2)
+	srv := NewServer()
+	srv.HandleFunc("ivr", func(ctx context.Context, agi *AGI) error {
+		started.Done()
+		started.Wait()
+		_, err := checkResponse(agi.Verbose(agi.Env("uniqueid")))
+		errs <- err
+		return err
+	})
+
+	var wg sync.WaitGroup
+	for _, resp := range []string{"510 Invalid or unknown command", "520 Invalid command syntax."} {
+		wg.Add(1)
+		go func(resp string) {
+			defer wg.Done()
+			serveTest(srv, "ivr", resp)
+		}(resp)
+	}
+	wg.Wait()
+	close(errs)
+
+	msgs := make([]string, 0)
+	for err := range errs {
+		assert.True(t, errors.Is(err, ErrCommand))
+		msgs = append(msgs, err.Error())
+	}
+	assert.ElementsMatch(t, []string{
+		"AGI command: 510 Invalid or unknown command",
+		"AGI command: 520 Invalid command syntax.",
+	}, msgs)
+}
+
+func TestServerSetupTimeout(t *testing.T) {
+	log := &testLog{}
+	srv := NewServer()
+	srv.ErrorLog = log
+	srv.SetupTimeout = 10 * time.Millisecond
+	assert.Equal(t, DefaultServerSetupTimeout, NewServer().SetupTimeout)
+
+	// peer connects and sends nothing
+	client, server := net.Pipe()
+	defer client.Close()
+	done := make(chan struct{})
+	go func() {
+		srv.ServeConn(server)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("session setup did not time out")
+	}
+	assert.Contains(t, log.String(), "setup timeout after 10ms")
+}
+
+func TestServerListen(t *testing.T) {
+	srv := NewServer()
+	done := make(chan string, 1)
+	srv.HandleFunc("hello", func(ctx context.Context, agi *AGI) error {
+		done <- agi.Env("uniqueid")
+		return nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ln) }()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	assert.Nil(t, err)
+	cmds := fakeAsterisk(conn, "hello")
+	assert.Equal(t, "1700.1", <-done)
+	<-cmds
+
+	assert.Nil(t, srv.Close())
+	select {
+	case err := <-served:
+		assert.True(t, errors.Is(err, ErrServerClosed))
+	case <-time.After(time.Second):
+		t.Fatal("server did not stop")
+	}
+	assert.True(t, errors.Is(srv.Serve(ln), ErrServerClosed))
+}
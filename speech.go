@@ -0,0 +1,303 @@
+package goagi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Default speech listening timeouts
+const (
+	DefaultNoInputTimeout = 5 * time.Second
+	DefaultMaxSpeech      = 15 * time.Second
+)
+
+// Transcript is recognition alternative
+type Transcript struct {
+	Text       string
+	Confidence float64
+}
+
+// Recognizer is streaming speech recognizer. New recognizer is used for
+// every utterance.
+type Recognizer interface {
+	// Feed sends audio frame: signed linear 16-bit little-endian 8kHz mono
+	Feed(frame []byte) error
+	// Finish ends utterance and returns alternatives ordered by confidence
+	Finish() ([]Transcript, error)
+}
+
+// EAGIAudio returns EAGI audio stream from file descriptor 3.
+// Audio is available only when script is started with EAGI() application.
+func EAGIAudio() *os.File {
+	return os.NewFile(3, "eagi-audio")
+}
+
+// IsEnhanced returns true if session is started with EAGI
+func (agi *AGI) IsEnhanced() bool {
+	return agi.Env("enhanced") == "1.0"
+}
+
+// ListenStatus status of speech listening
+type ListenStatus int
+
+// Speech listening statuses
+const (
+	// ListenRecognized speech is recognized
+	ListenRecognized ListenStatus = iota
+	// ListenNoMatch speech is detected but recognizer returned no alternatives
+	ListenNoMatch
+	// ListenNoInput no speech detected in time
+	ListenNoInput
+	// ListenDTMF prompt interrupted by DTMF
+	ListenDTMF
+	// ListenHangup channel hung up
+	ListenHangup
+)
+
+// String returns status name
+func (s ListenStatus) String() string {
+	switch s {
+	case ListenRecognized:
+		return "recognized"
+	case ListenNoMatch:
+		return "nomatch"
+	case ListenNoInput:
+		return "noinput"
+	case ListenDTMF:
+		return "dtmf"
+	case ListenHangup:
+		return "hangup"
+	}
+	return fmt.Sprintf("unknown(%d)", int(s))
+}
+
+// ListenOptions options of speech listening
+type ListenOptions struct {
+	// Prompt sound file played while listening
+	Prompt string
+	// Escape digits that interrupt prompt
+	Escape Digits
+	// NoInputTimeout time to wait for speech after prompt.
+	// DefaultNoInputTimeout if zero
+	NoInputTimeout time.Duration
+	// MaxSpeech maximum speech duration. DefaultMaxSpeech if zero
+	MaxSpeech time.Duration
+	// VAD voice activity detector parameters
+	VAD VAD
+}
+
+// ListenResult result of speech listening
+type ListenResult struct {
+	Status ListenStatus
+	// Alternatives returned by recognizer
+	Alternatives []Transcript
+	// Digit that interrupted prompt
+	Digit string
+	// SpeechStart and SpeechEnd are offsets from the beginning of listening
+	SpeechStart, SpeechEnd time.Duration
+	// BargeIn is true if speech started while prompt was playing
+	BargeIn bool
+}
+
+// Best returns alternative with highest confidence or empty transcript
+func (r *ListenResult) Best() Transcript {
+	var best Transcript
+	for i, alt := range r.Alternatives {
+		if i == 0 || alt.Confidence > best.Confidence {
+			best = alt
+		}
+	}
+	return best
+}
+
+type promptResult struct {
+	resp Response
+	err  error
+}
+
+/*
+Listen plays optional prompt and streams caller speech from EAGI audio to
+recognizer. Speech is detected with VAD and ends after end-of-speech
+silence or maximum speech duration.
+
+Audio is read during prompt playback, so speech that starts over the
+prompt is not lost and BargeIn is set in the result. Recognizer is finished
+as soon as speech ends, but AGI can not stop STREAM FILE by voice: prompt
+is interrupted only by escape digits and Listen returns when prompt
+playback is over. Keep prompts short or play them before Listen when
+callers are expected to talk over them.
+
+Example:
+
+	rec := myengine.NewRecognizer("en-US")
+	res, err := agi.Listen(goagi.EAGIAudio(), rec, goagi.ListenOptions{
+		Prompt: "say-your-city",
+		Escape: "#",
+	})
+	if err == nil && res.Status == goagi.ListenRecognized {
+		city := res.Best().Text
+	}
+*/
+func (agi *AGI) Listen(audio io.Reader, rec Recognizer, opts ListenOptions) (*ListenResult, error) {
+	if err := opts.Escape.Validate(); err != nil {
+		return nil, err
+	}
+	vad := opts.VAD
+	vad.Reset()
+	noInput := orDefaultDuration(opts.NoInputTimeout, DefaultNoInputTimeout)
+	maxSpeech := orDefaultDuration(opts.MaxSpeech, DefaultMaxSpeech)
+
+	var prompt chan promptResult
+	var promptEnd time.Duration
+	if opts.Prompt != "" {
+		prompt = make(chan promptResult, 1)
+		go func() {
+			resp, err := agi.StreamFile(opts.Prompt, string(opts.Escape), 0)
+			prompt <- promptResult{resp, err}
+		}()
+	}
+
+	res := &ListenResult{Status: ListenNoInput}
+	var promptRes *promptResult
+	started := false
+	preroll := make([][]byte, 0)
+	prerollMax := int(orDefaultDuration(vad.MinSpeech, DefaultVADMinSpeech)/(20*time.Millisecond)) + 1
+
+	for {
+		if prompt != nil && promptRes == nil {
+			select {
+			case r := <-prompt:
+				promptRes = &r
+				promptEnd = vad.Position()
+			default:
+			}
+		}
+		if promptRes != nil && (promptRes.err != nil || promptRes.resp.Result() != 0) {
+			break
+		}
+
+		frame := make([]byte, AudioFrameSize)
+		if _, err := io.ReadFull(audio, frame); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			waitPrompt(prompt, promptRes)
+			return nil, err
+		}
+
+		ev := vad.Process(frame)
+		if !started {
+			preroll = append(preroll, frame)
+			if len(preroll) > prerollMax {
+				preroll = preroll[1:]
+			}
+			if ev.Type != VADSpeechStart {
+				if (prompt == nil || promptRes != nil) && vad.Position()-promptEnd >= noInput {
+					break
+				}
+				continue
+			}
+			started = true
+			res.SpeechStart = ev.At
+			res.BargeIn = prompt != nil && promptRes == nil
+			for _, f := range preroll {
+				if err := rec.Feed(f); err != nil {
+					waitPrompt(prompt, promptRes)
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		if err := rec.Feed(frame); err != nil {
+			waitPrompt(prompt, promptRes)
+			return nil, err
+		}
+		if ev.Type == VADSpeechEnd {
+			res.SpeechEnd = ev.At
+			break
+		}
+		if vad.Position()-res.SpeechStart >= maxSpeech {
+			res.SpeechEnd = vad.Position()
+			break
+		}
+	}
+
+	if started {
+		if res.SpeechEnd == 0 {
+			res.SpeechEnd = vad.Position()
+		}
+		alts, err := rec.Finish()
+		if err != nil {
+			waitPrompt(prompt, promptRes)
+			return nil, err
+		}
+		res.Alternatives = alts
+		res.Status = ListenRecognized
+		if len(alts) == 0 {
+			res.Status = ListenNoMatch
+		}
+	}
+
+	pr := waitPrompt(prompt, promptRes)
+	if pr != nil {
+		if pr.err != nil {
+			return nil, pr.err
+		}
+		switch {
+		case pr.resp.Code() == codeE511 || pr.resp.Result() < 0 || agi.IsHungup():
+			res.Status = ListenHangup
+		case pr.resp.Code() != codeSucc:
+			return nil, ErrCommand.Msg("%d %s", pr.resp.Code(), pr.resp.Data())
+		case pr.resp.Result() > 0:
+			res.Status = ListenDTMF
+			res.Digit = string(rune(pr.resp.Result()))
+		}
+	}
+	return res, nil
+}
+
+// waitPrompt waits for prompt playback to finish
+func waitPrompt(prompt chan promptResult, res *promptResult) *promptResult {
+	if prompt == nil || res != nil {
+		return res
+	}
+	r := <-prompt
+	return &r
+}
+
+/*
+FakeRecognizer is deterministic recognizer for tests. It counts fed audio
+and returns configured alternatives if any audio was fed.
+*/
+type FakeRecognizer struct {
+	// Alternatives returned by Finish
+	Alternatives []Transcript
+	// Err returned by Feed and Finish
+	Err error
+	// Bytes is number of fed audio bytes
+	Bytes int
+}
+
+// Feed counts audio bytes
+func (r *FakeRecognizer) Feed(frame []byte) error {
+	if r.Err != nil {
+		return r.Err
+	}
+	r.Bytes += len(frame)
+	return nil
+}
+
+// Finish returns configured alternatives
+func (r *FakeRecognizer) Finish() ([]Transcript, error) {
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	if r.Bytes == 0 {
+		return nil, nil
+	}
+	return r.Alternatives, nil
+}
@@ -0,0 +1,213 @@
+package goagi
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenStatusString(t *testing.T) {
+	assert.Equal(t, "recognized", ListenRecognized.String())
+	assert.Equal(t, "nomatch", ListenNoMatch.String())
+	assert.Equal(t, "noinput", ListenNoInput.String())
+	assert.Equal(t, "dtmf", ListenDTMF.String())
+	assert.Equal(t, "hangup", ListenHangup.String())
+	assert.Equal(t, "unknown(9)", ListenStatus(9).String())
+}
+
+func TestIsEnhanced(t *testing.T) {
+	agi := &AGI{env: map[string]string{"enhanced": "1.0"}}
+	assert.True(t, agi.IsEnhanced())
+	agi.env["enhanced"] = "0.0"
+	assert.False(t, agi.IsEnhanced())
+}
+
+func TestListenRecognized(t *testing.T) {
+	audio := testAudio(
+		0, 500*time.Millisecond,
+		3000, time.Second,
+		0, time.Second,
+		3000, time.Second, // not read
+	)
+	rec := &FakeRecognizer{Alternatives: []Transcript{
+		{"montreal", 0.7}, {"toronto", 0.9},
+	}}
+	agi, buf := mockAGIScript()
+	res, err := agi.Listen(bytes.NewReader(audio), rec, ListenOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, ListenRecognized, res.Status)
+	assert.Equal(t, "toronto", res.Best().Text)
+	assert.Equal(t, 500*time.Millisecond, res.SpeechStart)
+	assert.Equal(t, 1500*time.Millisecond, res.SpeechEnd)
+	// speech with pre-roll frames from 480ms and trailing silence till 2300ms
+	assert.Equal(t, len(testAudio(0, 1820*time.Millisecond)), rec.Bytes)
+	assert.Empty(t, buf.String())
+}
+
+func TestListenWithPrompt(t *testing.T) {
+	audio := testAudio(
+		0, 200*time.Millisecond,
+		3000, 500*time.Millisecond,
+		0, time.Second,
+	)
+	rec := &FakeRecognizer{}
+	agi, buf := mockAGIScript("200 result=0 endpos=8000")
+	res, err := agi.Listen(bytes.NewReader(audio), rec, ListenOptions{Prompt: "say-city", Escape: "#"})
+	assert.Nil(t, err)
+	assert.Equal(t, ListenNoMatch, res.Status)
+	assert.Equal(t, 200*time.Millisecond, res.SpeechStart)
+	assert.Equal(t, 700*time.Millisecond, res.SpeechEnd)
+	assert.Equal(t, "STREAM FILE say-city \"#\" 0\n", buf.String())
+}
+
+// bargeInRecognizer unblocks prompt playback when recognizer is finished
+type bargeInRecognizer struct {
+	FakeRecognizer
+	done chan struct{}
+}
+
+func (r *bargeInRecognizer) Finish() ([]Transcript, error) {
+	close(r.done)
+	return r.FakeRecognizer.Finish()
+}
+
+// blockingReader returns script after channel is closed
+type blockingReader struct {
+	wait   chan struct{}
+	script *scriptReader
+}
+
+func (r *blockingReader) Read(b []byte) (int, error) {
+	<-r.wait
+	return r.script.Read(b)
+}
+
+func TestListenBargeIn(t *testing.T) {
+	audio := testAudio(
+		0, 200*time.Millisecond,
+		3000, 500*time.Millisecond,
+		0, time.Second,
+	)
+	rec := &bargeInRecognizer{
+		FakeRecognizer: FakeRecognizer{Alternatives: []Transcript{{"yes", 1}}},
+		done:           make(chan struct{}),
+	}
+	// prompt ends only after recognizer is finished
+	script := &scriptReader{lines: []string{"200 result=0 endpos=8000\n"}}
+	buf := new(bytes.Buffer)
+	agi := &AGI{reader: &blockingReader{rec.done, script}, writer: &stubWriter{buf}}
+	res, err := agi.Listen(bytes.NewReader(audio), rec, ListenOptions{Prompt: "say-city"})
+	assert.Nil(t, err)
+	assert.Equal(t, ListenRecognized, res.Status)
+	assert.True(t, res.BargeIn)
+	assert.Equal(t, "yes", res.Best().Text)
+
+	// no prompt to barge in
+	agi, _ = mockAGIScript()
+	res, err = agi.Listen(bytes.NewReader(audio), &FakeRecognizer{}, ListenOptions{})
+	assert.Nil(t, err)
+	assert.False(t, res.BargeIn)
+}
+
+func TestListenNoInputAndMaxSpeech(t *testing.T) {
+	agi, _ := mockAGIScript()
+	rec := &FakeRecognizer{Alternatives: []Transcript{{"yes", 1}}}
+	res, err := agi.Listen(bytes.NewReader(testAudio(0, 10*time.Second)), rec,
+		ListenOptions{NoInputTimeout: time.Second})
+	assert.Nil(t, err)
+	assert.Equal(t, ListenNoInput, res.Status)
+	assert.Equal(t, 0, rec.Bytes)
+
+	// audio ends before speech
+	res, err = agi.Listen(bytes.NewReader(testAudio(0, time.Second)), rec, ListenOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, ListenNoInput, res.Status)
+
+	res, err = agi.Listen(bytes.NewReader(testAudio(3000, 10*time.Second)), rec,
+		ListenOptions{MaxSpeech: 2 * time.Second})
+	assert.Nil(t, err)
+	assert.Equal(t, ListenRecognized, res.Status)
+	assert.Equal(t, time.Duration(0), res.SpeechStart)
+	assert.Equal(t, 2*time.Second, res.SpeechEnd)
+
+	// audio ends during speech
+	rec = &FakeRecognizer{Alternatives: []Transcript{{"yes", 1}}}
+	res, err = agi.Listen(bytes.NewReader(testAudio(3000, time.Second)), rec, ListenOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, ListenRecognized, res.Status)
+	assert.Equal(t, time.Second, res.SpeechEnd)
+}
+
+func TestListenPromptInterrupted(t *testing.T) {
+	audio := testAudio(0, 10*time.Second)
+	tests := []struct {
+		response string
+		status   ListenStatus
+		digit    string
+	}{
+		{"200 result=35 endpos=100", ListenDTMF, "#"},
+		{"200 result=-1 endpos=100", ListenHangup, ""},
+		{"511 Command Not Permitted on a dead channel or intercept routine", ListenHangup, ""},
+	}
+	for _, tc := range tests {
+		agi, _ := mockAGIScript(tc.response)
+		res, err := agi.Listen(bytes.NewReader(audio), &FakeRecognizer{},
+			ListenOptions{Prompt: "say-city", Escape: "#"})
+		assert.Nil(t, err)
+		assert.Equal(t, tc.status, res.Status, tc.response)
+		assert.Equal(t, tc.digit, res.Digit)
+	}
+
+	agi, _ := mockAGIScript("510 Invalid or unknown command")
+	_, err := agi.Listen(bytes.NewReader(audio), &FakeRecognizer{}, ListenOptions{Prompt: "foo"})
+	assert.True(t, errors.Is(err, ErrCommand))
+
+	agi, _ = mockAGIScript()
+	_, err = agi.Listen(bytes.NewReader(audio), &FakeRecognizer{}, ListenOptions{Prompt: "foo"})
+	assert.NotNil(t, err)
+}
+
+func TestListenFail(t *testing.T) {
+	agi, _ := mockAGIScript()
+	_, err := agi.Listen(bytes.NewReader(nil), &FakeRecognizer{}, ListenOptions{Escape: "x"})
+	assert.True(t, errors.Is(err, ErrArgument))
+
+	fail := errors.New("recognizer failed")
+	_, err = agi.Listen(bytes.NewReader(testAudio(3000, time.Second)),
+		&FakeRecognizer{Err: fail}, ListenOptions{})
+	assert.Equal(t, fail, err)
+
+	rec := &FakeRecognizer{}
+	audio := testAudio(3000, 200*time.Millisecond)
+	reader := &failingReader{data: audio, err: errors.New("read failed")}
+	_, err = agi.Listen(reader, rec, ListenOptions{})
+	assert.Contains(t, err.Error(), "read failed")
+}
+
+type failingReader struct {
+	data []byte
+	err  error
+}
+
+func (r *failingReader) Read(b []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, r.err
+	}
+	n := copy(b, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestFakeRecognizer(t *testing.T) {
+	rec := &FakeRecognizer{Alternatives: []Transcript{{"yes", 1}}}
+	alts, err := rec.Finish()
+	assert.Nil(t, err)
+	assert.Nil(t, alts)
+	assert.Nil(t, rec.Feed(make([]byte, 10)))
+	alts, _ = rec.Finish()
+	assert.Equal(t, 1, len(alts))
+	assert.Equal(t, Transcript{}, (&ListenResult{}).Best())
+}
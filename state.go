@@ -0,0 +1,375 @@
+package goagi
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrState error returned when session state can not be loaded or saved
+var ErrState = newError("Session state")
+
+// DefaultStateFamily is AstDB family of AstDBStateBackend
+const DefaultStateFamily = "goagi-state"
+
+// StateBackend stores encoded session state by key. Load returns
+// ErrNotFound when state does not exist. AGI is passed for backends
+// that use AGI commands.
+type StateBackend interface {
+	Load(agi *AGI, key string) ([]byte, error)
+	Save(agi *AGI, key string, data []byte) error
+	Delete(agi *AGI, key string) error
+}
+
+// StateKeyFunc returns state key of the AGI session
+type StateKeyFunc func(agi *AGI) (string, error)
+
+// UniqueIDKey keys state by channel unique ID, so state is shared by
+// AGI invocations on the same channel
+func UniqueIDKey(agi *AGI) (string, error) {
+	if id := agi.Env("uniqueid"); id != "" {
+		return id, nil
+	}
+	return "", ErrState.Msg("agi_uniqueid is not set")
+}
+
+// LinkedIDKey keys state by CHANNEL(linkedid), so state is shared by
+// all channels of the call
+func LinkedIDKey(agi *AGI) (string, error) {
+	id, err := agi.ChannelItem("linkedid")
+	if err != nil {
+		return "", err
+	}
+	if id == "" {
+		return "", ErrState.Msg("CHANNEL(linkedid) is empty")
+	}
+	return id, nil
+}
+
+/*
+State is session state shared between AGI invocations of the call.
+Values are encoded to JSON.
+*/
+type State struct {
+	// Key of the state in backend
+	Key    string
+	values map[string]json.RawMessage
+}
+
+// NewState creates empty state with key
+func NewState(key string) *State {
+	return &State{Key: key, values: make(map[string]json.RawMessage)}
+}
+
+// Get decodes value of name to v. Returns false if value is not set.
+func (s *State) Get(name string, v interface{}) (bool, error) {
+	raw, ok := s.values[name]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, ErrState.Msg("failed to decode %q: %s", name, err)
+	}
+	return true, nil
+}
+
+// Set encodes and sets value of name
+func (s *State) Set(name string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return ErrState.Msg("failed to encode %q: %s", name, err)
+	}
+	s.values[name] = raw
+	return nil
+}
+
+// Delete removes value of name
+func (s *State) Delete(name string) { delete(s.values, name) }
+
+// Names returns sorted names of values
+func (s *State) Names() []string {
+	names := make([]string, 0, len(s.values))
+	for name := range s.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+StateStore loads and saves session state in backend. FastAGI server
+loads and saves state of every session with Middleware:
+
+	states := goagi.NewStateStore(goagi.NewMemoryStateBackend(time.Hour))
+	srv.Use(states.Middleware())
+	srv.HandleFunc("ivr", func(ctx context.Context, agi *goagi.AGI) error {
+		st := goagi.StateFrom(ctx)
+		var tries int
+		st.Get("tries", &tries)
+		return st.Set("tries", tries+1)
+	})
+
+AGI scripts without server use Run:
+
+	err := states.Run(agi, func(st *goagi.State) error { ... })
+*/
+type StateStore struct {
+	Backend StateBackend
+	// Key returns state key of session, UniqueIDKey by default
+	Key StateKeyFunc
+}
+
+// NewStateStore creates store keyed by unique ID
+func NewStateStore(backend StateBackend) *StateStore {
+	return &StateStore{Backend: backend, Key: UniqueIDKey}
+}
+
+// Load returns state of the session or empty state if not saved yet
+func (s *StateStore) Load(agi *AGI) (*State, error) {
+	keyFn := s.Key
+	if keyFn == nil {
+		keyFn = UniqueIDKey
+	}
+	key, err := keyFn(agi)
+	if err != nil {
+		return nil, err
+	}
+	st := NewState(key)
+	data, err := s.Backend.Load(agi, key)
+	if errors.Is(err, ErrNotFound) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &st.values); err != nil {
+		return nil, ErrState.Msg("failed to decode state %q: %s", key, err)
+	}
+	return st, nil
+}
+
+// Save saves state of the session
+func (s *StateStore) Save(agi *AGI, st *State) error {
+	data, err := json.Marshal(st.values)
+	if err != nil {
+		return ErrState.Msg("failed to encode state %q: %s", st.Key, err)
+	}
+	return s.Backend.Save(agi, st.Key, data)
+}
+
+// Delete removes state of the session, for example at the end of call
+func (s *StateStore) Delete(agi *AGI, st *State) error {
+	err := s.Backend.Delete(agi, st.Key)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Run loads state, calls fn and saves state when fn returns.
+// State is saved even if fn fails, fn error is returned first.
+func (s *StateStore) Run(agi *AGI, fn func(st *State) error) error {
+	st, err := s.Load(agi)
+	if err != nil {
+		return err
+	}
+	ferr := fn(st)
+	serr := s.Save(agi, st)
+	if ferr != nil {
+		return ferr
+	}
+	return serr
+}
+
+// stateContextKey is context key of session state
+type stateContextKey struct{}
+
+// Middleware loads session state before handler and saves it when handler
+// returns, like Run. Handler gets state from context with StateFrom.
+func (s *StateStore) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, agi *AGI) error {
+			return s.Run(agi, func(st *State) error {
+				return next.ServeAGI(context.WithValue(ctx, stateContextKey{}, st), agi)
+			})
+		})
+	}
+}
+
+// StateFrom returns session state loaded by StateStore.Middleware or nil
+func StateFrom(ctx context.Context) *State {
+	st, _ := ctx.Value(stateContextKey{}).(*State)
+	return st
+}
+
+// MemoryStateBackend keeps state in memory of FastAGI server process
+// and expires it after TTL since last save
+type MemoryStateBackend struct {
+	ttl   time.Duration
+	mu    sync.Mutex
+	items map[string]memoryState
+}
+
+type memoryState struct {
+	data   []byte
+	expire time.Time
+}
+
+// NewMemoryStateBackend creates memory backend. Zero TTL never expires.
+func NewMemoryStateBackend(ttl time.Duration) *MemoryStateBackend {
+	return &MemoryStateBackend{ttl: ttl, items: make(map[string]memoryState)}
+}
+
+// Load returns state data
+func (m *MemoryStateBackend) Load(_ *AGI, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.items[key]
+	if !ok || m.expired(item, time.Now()) {
+		delete(m.items, key)
+		return nil, ErrNotFound.Msg("state %q", key)
+	}
+	return item.data, nil
+}
+
+// Save stores state data and removes expired states
+func (m *MemoryStateBackend) Save(_ *AGI, key string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for k, item := range m.items {
+		if m.expired(item, now) {
+			delete(m.items, k)
+		}
+	}
+	item := memoryState{data: append([]byte(nil), data...)}
+	if m.ttl > 0 {
+		item.expire = now.Add(m.ttl)
+	}
+	m.items[key] = item
+	return nil
+}
+
+// Delete removes state data
+func (m *MemoryStateBackend) Delete(_ *AGI, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.items[key]; !ok {
+		return ErrNotFound.Msg("state %q", key)
+	}
+	delete(m.items, key)
+	return nil
+}
+
+// Len returns number of stored states
+func (m *MemoryStateBackend) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.items)
+}
+
+func (m *MemoryStateBackend) expired(item memoryState, now time.Time) bool {
+	return !item.expire.IsZero() && now.After(item.expire)
+}
+
+// FileStateBackend keeps state in files of directory, so it survives
+// FastAGI server restarts. States older than TTL are not loaded.
+type FileStateBackend struct {
+	dir string
+	ttl time.Duration
+}
+
+// NewFileStateBackend creates file backend. Zero TTL never expires.
+func NewFileStateBackend(dir string, ttl time.Duration) *FileStateBackend {
+	return &FileStateBackend{dir: dir, ttl: ttl}
+}
+
+func (f *FileStateBackend) path(key string) string {
+	return filepath.Join(f.dir, hex.EncodeToString([]byte(key))+".json")
+}
+
+// Load returns state data
+func (f *FileStateBackend) Load(_ *AGI, key string) ([]byte, error) {
+	path := f.path(key)
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound.Msg("state %q", key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if f.ttl > 0 && time.Since(info.ModTime()) > f.ttl {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		return nil, ErrNotFound.Msg("state %q", key)
+	}
+	return os.ReadFile(path)
+}
+
+// Save writes state data to file
+func (f *FileStateBackend) Save(_ *AGI, key string, data []byte) error {
+	if err := os.MkdirAll(f.dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(f.dir, ".state-*")
+	if err != nil {
+		return err
+	}
+	// temporary file is already renamed on success
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path(key))
+}
+
+// Delete removes state file
+func (f *FileStateBackend) Delete(_ *AGI, key string) error {
+	err := os.Remove(f.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound.Msg("state %q", key)
+	}
+	return err
+}
+
+// AstDBStateBackend keeps state in Asterisk database, so it is shared
+// by FastAGI servers of the same Asterisk
+type AstDBStateBackend struct {
+	// Family of AstDB keys
+	Family string
+}
+
+// NewAstDBStateBackend creates AstDB backend with DefaultStateFamily
+func NewAstDBStateBackend() *AstDBStateBackend {
+	return &AstDBStateBackend{Family: DefaultStateFamily}
+}
+
+// Load returns state data
+func (a *AstDBStateBackend) Load(agi *AGI, key string) ([]byte, error) {
+	val, err := NewStore(agi).GetRaw(a.Family, key)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(val), nil
+}
+
+// Save writes state data to AstDB
+func (a *AstDBStateBackend) Save(agi *AGI, key string, data []byte) error {
+	return NewStore(agi).PutRaw(a.Family, key, string(data))
+}
+
+// Delete removes state data from AstDB
+func (a *AstDBStateBackend) Delete(agi *AGI, key string) error {
+	return NewStore(agi).Delete(a.Family, key)
+}
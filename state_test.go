@@ -0,0 +1,199 @@
+package goagi
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestState(t *testing.T) {
+	st := NewState("1700.1")
+	assert.Nil(t, st.Set("tries", 2))
+	assert.Nil(t, st.Set("account", "1234"))
+
+	var tries int
+	ok, err := st.Get("tries", &tries)
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, tries)
+
+	var account int
+	ok, err = st.Get("account", &account)
+	assert.True(t, ok)
+	assert.True(t, errors.Is(err, ErrState))
+
+	ok, _ = st.Get("missing", &tries)
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"account", "tries"}, st.Names())
+	st.Delete("account")
+	assert.Equal(t, []string{"tries"}, st.Names())
+
+	assert.True(t, errors.Is(st.Set("bad", make(chan int)), ErrState))
+}
+
+func TestStateKeys(t *testing.T) {
+	agi := &AGI{env: map[string]string{"uniqueid": "1700.1"}}
+	key, err := UniqueIDKey(agi)
+	assert.Nil(t, err)
+	assert.Equal(t, "1700.1", key)
+
+	_, err = UniqueIDKey(&AGI{})
+	assert.True(t, errors.Is(err, ErrState))
+
+	agi, buf := mockAGI("200 result=1 (1700.0)")
+	key, err = LinkedIDKey(agi)
+	assert.Nil(t, err)
+	assert.Equal(t, "1700.0", key)
+	assert.Equal(t, "GET FULL VARIABLE \"${CHANNEL(linkedid)}\"\n", buf.String())
+
+	agi, _ = mockAGI("200 result=1 ()")
+	_, err = LinkedIDKey(agi)
+	assert.True(t, errors.Is(err, ErrState))
+}
+
+func TestStateStoreMemory(t *testing.T) {
+	backend := NewMemoryStateBackend(time.Hour)
+	store := NewStateStore(backend)
+
+	// first AGI invocation of the call
+	agi := &AGI{env: map[string]string{"uniqueid": "1700.1"}}
+	err := store.Run(agi, func(st *State) error {
+		assert.Empty(t, st.Names())
+		return st.Set("step", "menu")
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, backend.Len())
+
+	// next AGI invocation with new connection
+	agi = &AGI{env: map[string]string{"uniqueid": "1700.1"}}
+	fail := errors.New("handler failed")
+	err = store.Run(agi, func(st *State) error {
+		var step string
+		ok, _ := st.Get("step", &step)
+		assert.True(t, ok)
+		assert.Equal(t, "menu", step)
+		assert.Nil(t, st.Set("step", "transfer"))
+		return fail
+	})
+	assert.Equal(t, fail, err)
+
+	// state is saved when handler fails
+	st, err := store.Load(agi)
+	assert.Nil(t, err)
+	var step string
+	st.Get("step", &step)
+	assert.Equal(t, "transfer", step)
+
+	assert.Nil(t, store.Delete(agi, st))
+	assert.Nil(t, store.Delete(agi, st))
+	assert.Equal(t, 0, backend.Len())
+
+	_, err = store.Load(&AGI{})
+	assert.True(t, errors.Is(err, ErrState))
+}
+
+func TestStateStoreMiddleware(t *testing.T) {
+	store := NewStateStore(NewMemoryStateBackend(time.Hour))
+	srv := NewServer()
+	srv.Use(store.Middleware())
+	tries := make([]int, 0)
+	srv.HandleFunc("ivr", func(ctx context.Context, agi *AGI) error {
+		st := StateFrom(ctx)
+		var n int
+		st.Get("tries", &n)
+		tries = append(tries, n)
+		return st.Set("tries", n+1)
+	})
+
+	// two AGI invocations of the same call
+	serveTest(srv, "ivr")
+	serveTest(srv, "ivr")
+	assert.Equal(t, []int{0, 1}, tries)
+
+	assert.Nil(t, StateFrom(context.Background()))
+}
+
+func TestMemoryStateBackendTTL(t *testing.T) {
+	backend := NewMemoryStateBackend(time.Millisecond)
+	assert.Nil(t, backend.Save(nil, "a", []byte("{}")))
+	time.Sleep(5 * time.Millisecond)
+	_, err := backend.Load(nil, "a")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	// expired states are removed on save
+	assert.Nil(t, backend.Save(nil, "b", []byte("{}")))
+	time.Sleep(5 * time.Millisecond)
+	assert.Nil(t, backend.Save(nil, "c", []byte("{}")))
+	assert.Equal(t, 1, backend.Len())
+
+	assert.True(t, errors.Is(backend.Delete(nil, "x"), ErrNotFound))
+}
+
+func TestFileStateBackend(t *testing.T) {
+	dir := t.TempDir()
+	backend := NewFileStateBackend(dir, time.Hour)
+	store := NewStateStore(backend)
+	agi := &AGI{env: map[string]string{"uniqueid": "1700.1"}}
+
+	st, err := store.Load(agi)
+	assert.Nil(t, err)
+	assert.Nil(t, st.Set("lang", "fr"))
+	assert.Nil(t, store.Save(agi, st))
+
+	// state survives new backend instance
+	store = NewStateStore(NewFileStateBackend(dir, time.Hour))
+	st, err = store.Load(agi)
+	assert.Nil(t, err)
+	var lang string
+	st.Get("lang", &lang)
+	assert.Equal(t, "fr", lang)
+
+	// expired state
+	path := backend.path("1700.1")
+	old := time.Now().Add(-2 * time.Hour)
+	assert.Nil(t, os.Chtimes(path, old, old))
+	st, err = store.Load(agi)
+	assert.Nil(t, err)
+	assert.Empty(t, st.Names())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	assert.True(t, errors.Is(backend.Delete(nil, "1700.1"), ErrNotFound))
+
+	// corrupted state
+	assert.Nil(t, os.WriteFile(path, []byte("{bad"), 0o644))
+	_, err = store.Load(agi)
+	assert.True(t, errors.Is(err, ErrState))
+}
+
+func TestAstDBStateBackend(t *testing.T) {
+	store := NewStateStore(NewAstDBStateBackend())
+
+	agi, buf := mockAGIScript("200 result=0", "200 result=1")
+	agi.env = map[string]string{"uniqueid": "1700.1"}
+	err := store.Run(agi, func(st *State) error {
+		return st.Set("tries", 1)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "DATABASE GET goagi-state 1700.1\n"+
+		`DATABASE PUT goagi-state 1700.1 "{\"tries\":1}"`+"\n", buf.String())
+
+	agi, _ = mockAGIScript(`200 result=1 ({"tries":1})`, "200 result=1")
+	agi.env = map[string]string{"uniqueid": "1700.1"}
+	st, err := store.Load(agi)
+	assert.Nil(t, err)
+	var tries int
+	st.Get("tries", &tries)
+	assert.Equal(t, 1, tries)
+	assert.Nil(t, store.Delete(agi, st))
+
+	agi, _ = mockAGI("511 Command Not Permitted on a dead channel")
+	agi.env = map[string]string{"uniqueid": "1700.1"}
+	_, err = store.Load(agi)
+	assert.True(t, errors.Is(err, ErrCommand))
+}
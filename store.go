@@ -0,0 +1,297 @@
+package goagi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound error returned when key does not exist
+var ErrNotFound = newError("Not found")
+
+// ErrStore error returned by Store when operation fails
+var ErrStore = newError("AstDB store")
+
+// Store default lock parameters
+const (
+	DefaultLockFamily  = "goagi-lock"
+	DefaultLockTimeout = 2 * time.Second
+	DefaultLockTTL     = 10 * time.Second
+	lockRetryInterval  = 50 * time.Millisecond
+)
+
+/*
+Store is key/value store on top of Asterisk database (AstDB) commands.
+Values are encoded to JSON.
+
+AstDB has no atomic operations over AGI, so CompareAndSwap is emulated
+with lock key per family in LockFamily. The lock is best-effort: it is
+respected only by Store users, expires after LockTTL if session dies
+holding it, and two sessions that write lock key at the same moment may
+both read back their own value. Do not use it where lost updates are not
+acceptable. CompareAndSwap returns ErrStore if lock was taken over before
+it was released.
+
+Family and key are sent as AGI command arguments, so they can not contain
+spaces or quotes.
+
+	store := goagi.NewStore(agi)
+	var profile Profile
+	if err := store.Get("profile", agi.Env("callerid"), &profile); errors.Is(err, goagi.ErrNotFound) {
+		...
+	}
+*/
+type Store struct {
+	// LockFamily is AstDB family for lock keys
+	LockFamily string
+	// LockTimeout limits time to acquire lock
+	LockTimeout time.Duration
+	// LockTTL is time after lock is considered stale
+	LockTTL time.Duration
+
+	agi *AGI
+}
+
+// NewStore creates AstDB store with default lock parameters
+func NewStore(agi *AGI) *Store {
+	return &Store{
+		LockFamily:  DefaultLockFamily,
+		LockTimeout: DefaultLockTimeout,
+		LockTTL:     DefaultLockTTL,
+		agi:         agi,
+	}
+}
+
+// GetRaw returns value of key. Returns ErrNotFound if key does not exist.
+func (s *Store) GetRaw(family, key string) (string, error) {
+	val, ok, err := s.get(family, key)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", ErrNotFound.Msg("%s/%s", family, key)
+	}
+	return val, nil
+}
+
+// PutRaw sets value of key
+func (s *Store) PutRaw(family, key, value string) error {
+	if strings.ContainsAny(value, "\r\n") {
+		return ErrArgument.Msg("value of %s/%s has new line", family, key)
+	}
+	if err := dbCheck(family, key); err != nil {
+		return err
+	}
+	resp, err := checkResponse(s.agi.DatabasePut(family, key, quoteArg(value)))
+	if err != nil {
+		return err
+	}
+	if resp.Result() != 1 {
+		return ErrStore.Msg("failed to put %s/%s", family, key)
+	}
+	return nil
+}
+
+// Get decodes JSON value of key to v.
+// Returns ErrNotFound if key does not exist.
+func (s *Store) Get(family, key string, v interface{}) error {
+	val, err := s.GetRaw(family, key)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(val), v); err != nil {
+		return ErrStore.Msg("failed to decode %s/%s: %s", family, key, err)
+	}
+	return nil
+}
+
+// Put encodes v to JSON and stores as value of key
+func (s *Store) Put(family, key string, v interface{}) error {
+	val, err := json.Marshal(v)
+	if err != nil {
+		return ErrStore.Msg("failed to encode %s/%s: %s", family, key, err)
+	}
+	return s.PutRaw(family, key, string(val))
+}
+
+// Exists returns true if key exists
+func (s *Store) Exists(family, key string) (bool, error) {
+	_, ok, err := s.get(family, key)
+	return ok, err
+}
+
+// Delete deletes key. Returns ErrNotFound if key does not exist.
+func (s *Store) Delete(family, key string) error {
+	if err := dbCheck(family, key); err != nil {
+		return err
+	}
+	resp, err := checkResponse(s.agi.DatabaseDel(family, key))
+	if err != nil {
+		return err
+	}
+	if resp.Result() != 1 {
+		return ErrNotFound.Msg("%s/%s", family, key)
+	}
+	return nil
+}
+
+// DeleteTree deletes family or keytree within family. Returns ErrNotFound
+// if nothing was deleted.
+func (s *Store) DeleteTree(family, keytree string) error {
+	if err := dbCheck(family, keytree); err != nil {
+		return err
+	}
+	resp, err := checkResponse(s.agi.DatabaseDelTree(family, keytree))
+	if err != nil {
+		return err
+	}
+	if resp.Result() != 1 {
+		return ErrNotFound.Msg("%s/%s", family, keytree)
+	}
+	return nil
+}
+
+// Keys returns keys of the family next level using DB_KEYS function
+func (s *Store) Keys(family string) ([]string, error) {
+	val, err := s.agi.Func("DB_KEYS", family)
+	if err != nil || val == "" {
+		return nil, err
+	}
+	return strings.Split(val, ","), nil
+}
+
+/*
+CompareAndSwap sets key to new value if current value equals old.
+Nil old value means key must not exist. Returns false if current
+value differs. Values are compared in JSON encoding. When value was
+written but lock was lost before release, returns true with ErrStore.
+*/
+func (s *Store) CompareAndSwap(family, key string, old, new interface{}) (bool, error) {
+	if err := dbCheck(family, key); err != nil {
+		return false, err
+	}
+	newVal, err := json.Marshal(new)
+	if err != nil {
+		return false, ErrStore.Msg("failed to encode %s/%s: %s", family, key, err)
+	}
+	if err := s.lock(family); err != nil {
+		return false, err
+	}
+	swapped, err := s.swap(family, key, old, string(newVal))
+	if uerr := s.unlock(family); err == nil {
+		err = uerr
+	}
+	return swapped, err
+}
+
+func (s *Store) swap(family, key string, old interface{}, newVal string) (bool, error) {
+	cur, exists, err := s.get(family, key)
+	if err != nil {
+		return false, err
+	}
+	if old == nil {
+		if exists {
+			return false, nil
+		}
+	} else {
+		oldVal, err := json.Marshal(old)
+		if err != nil {
+			return false, ErrStore.Msg("failed to encode %s/%s: %s", family, key, err)
+		}
+		if !exists || cur != string(oldVal) {
+			return false, nil
+		}
+	}
+	if err := s.PutRaw(family, key, newVal); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *Store) get(family, key string) (string, bool, error) {
+	if err := dbCheck(family, key); err != nil {
+		return "", false, err
+	}
+	resp, err := checkResponse(s.agi.DatabaseGet(family, key))
+	if err != nil {
+		return "", false, err
+	}
+	if resp.Result() != 1 {
+		return "", false, nil
+	}
+	return resp.Value(), true, nil
+}
+
+// lockToken identifies session holding lock
+func (s *Store) lockToken() string {
+	if id := s.agi.Env("uniqueid"); id != "" {
+		return id
+	}
+	return fmt.Sprintf("%p", s.agi)
+}
+
+// lock acquires family lock. Lock value is "token;expire".
+func (s *Store) lock(family string) error {
+	token := s.lockToken()
+	deadline := time.Now().Add(s.LockTimeout)
+	for {
+		val, held, err := s.get(s.LockFamily, family)
+		if err != nil {
+			return err
+		}
+		if held && !lockExpired(val) {
+			if time.Now().After(deadline) {
+				return ErrStore.Msg("failed to lock %q: held by %q", family, val)
+			}
+			time.Sleep(lockRetryInterval)
+			continue
+		}
+
+		expire := time.Now().Add(s.LockTTL).Unix()
+		lock := token + ";" + strconv.FormatInt(expire, 10)
+		if err := s.PutRaw(s.LockFamily, family, lock); err != nil {
+			return err
+		}
+		// read back to detect concurrent writer
+		val, held, err = s.get(s.LockFamily, family)
+		if err != nil {
+			return err
+		}
+		if held && strings.HasPrefix(val, token+";") {
+			return nil
+		}
+	}
+}
+
+// unlock releases family lock if it is still held by this session
+func (s *Store) unlock(family string) error {
+	val, held, err := s.get(s.LockFamily, family)
+	if err != nil {
+		return err
+	}
+	if !held || !strings.HasPrefix(val, s.lockToken()+";") {
+		return ErrStore.Msg("lock of %q is lost: held by %q", family, val)
+	}
+	_, err = checkResponse(s.agi.DatabaseDel(s.LockFamily, family))
+	return err
+}
+
+// dbCheck validates AstDB family and key that are sent unquoted as AGI
+// command arguments
+func dbCheck(family, key string) error {
+	if family == "" || strings.ContainsAny(family+key, " \t\r\n\"\\") {
+		return ErrArgument.Msg("invalid AstDB family %q or key %q", family, key)
+	}
+	return nil
+}
+
+func lockExpired(val string) bool {
+	idx := strings.LastIndexByte(val, ';')
+	if idx < 0 {
+		return true
+	}
+	expire, err := strconv.ParseInt(val[idx+1:], 10, 64)
+	return err != nil || time.Now().Unix() > expire
+}
@@ -0,0 +1,179 @@
+package goagi
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testProfile struct {
+	Name string `json:"name"`
+	VIP  bool   `json:"vip"`
+}
+
+func TestStoreGetPut(t *testing.T) {
+	agi, buf := mockAGI("200 result=1")
+	store := NewStore(agi)
+	err := store.Put("profile", "100", testProfile{"Bob \"B\"", true})
+	assert.Nil(t, err)
+	assert.Equal(t,
+		`DATABASE PUT profile 100 "{\"name\":\"Bob \\\"B\\\"\",\"vip\":true}"`+"\n",
+		buf.String())
+
+	agi, buf = mockAGI(`200 result=1 ({"name":"Bob (B)","vip":true})`)
+	var p testProfile
+	assert.Nil(t, NewStore(agi).Get("profile", "100", &p))
+	assert.Equal(t, testProfile{"Bob (B)", true}, p)
+	assert.Equal(t, "DATABASE GET profile 100\n", buf.String())
+
+	agi, _ = mockAGI("200 result=0")
+	err = NewStore(agi).Get("profile", "200", &p)
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.Contains(t, err.Error(), "profile/200")
+
+	agi, _ = mockAGI("200 result=1 (not json)")
+	err = NewStore(agi).Get("profile", "100", &p)
+	assert.True(t, errors.Is(err, ErrStore))
+
+	agi, _ = mockAGI("200 result=0")
+	err = NewStore(agi).PutRaw("profile", "100", "value")
+	assert.True(t, errors.Is(err, ErrStore))
+
+	err = NewStore(agi).PutRaw("profile", "100", "multi\nline")
+	assert.True(t, errors.Is(err, ErrArgument))
+}
+
+func TestStoreExists(t *testing.T) {
+	agi, _ := mockAGIScript("200 result=1 ()", "200 result=0")
+	store := NewStore(agi)
+	ok, err := store.Exists("profile", "100")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = store.Exists("profile", "200")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestStoreDelete(t *testing.T) {
+	agi, buf := mockAGIScript("200 result=1", "200 result=0",
+		"200 result=1", "200 result=0")
+	store := NewStore(agi)
+	assert.Nil(t, store.Delete("profile", "100"))
+	assert.True(t, errors.Is(store.Delete("profile", "100"), ErrNotFound))
+	assert.Nil(t, store.DeleteTree("profile", ""))
+	assert.True(t, errors.Is(store.DeleteTree("profile", "sub"), ErrNotFound))
+	assert.Equal(t, "DATABASE DEL profile 100\nDATABASE DEL profile 100\n"+
+		"DATABASE DELTREE profile \nDATABASE DELTREE profile sub\n", buf.String())
+}
+
+func TestStoreInvalidKey(t *testing.T) {
+	agi, buf := mockAGI(respOk)
+	store := NewStore(agi)
+	_, err := store.GetRaw("my profile", "100")
+	assert.True(t, errors.Is(err, ErrArgument))
+	assert.True(t, errors.Is(store.PutRaw("profile", "1 00", "v"), ErrArgument))
+	assert.True(t, errors.Is(store.Delete("profile", `"100"`), ErrArgument))
+	assert.True(t, errors.Is(store.DeleteTree("", "sub"), ErrArgument))
+	_, err = store.Exists("profile", "100\n")
+	assert.True(t, errors.Is(err, ErrArgument))
+	_, err = store.CompareAndSwap("counter", "my calls", 1, 2)
+	assert.True(t, errors.Is(err, ErrArgument))
+	assert.NotContains(t, buf.String(), "calls")
+}
+
+func TestStoreKeys(t *testing.T) {
+	agi, buf := mockAGI("200 result=1 (100,200,300)")
+	keys, err := NewStore(agi).Keys("profile")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"100", "200", "300"}, keys)
+	assert.Equal(t, "GET FULL VARIABLE \"${DB_KEYS(profile)}\"\n", buf.String())
+
+	agi, _ = mockAGI("200 result=1 ()")
+	keys, err = NewStore(agi).Keys("empty")
+	assert.Nil(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestStoreCompareAndSwap(t *testing.T) {
+	lock := "200 result=1 (1700.1;" +
+		strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10) + ")"
+
+	agi, buf := mockAGIScript(
+		"200 result=0",     // lock free
+		"200 result=1",     // put lock
+		lock,               // read back
+		"200 result=1 (3)", // current value
+		"200 result=1",     // put new value
+		lock,               // check lock owner
+		"200 result=1",     // unlock
+	)
+	agi.env = map[string]string{"uniqueid": "1700.1"}
+	ok, err := NewStore(agi).CompareAndSwap("counter", "calls", 3, 4)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	cmds := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, cmds, 7)
+	assert.Equal(t, "DATABASE GET goagi-lock counter", cmds[0])
+	assert.True(t, strings.HasPrefix(cmds[1], `DATABASE PUT goagi-lock counter "1700.1;`))
+	assert.Equal(t, "DATABASE GET counter calls", cmds[3])
+	assert.Equal(t, `DATABASE PUT counter calls "4"`, cmds[4])
+	assert.Equal(t, "DATABASE GET goagi-lock counter", cmds[5])
+	assert.Equal(t, "DATABASE DEL goagi-lock counter", cmds[6])
+
+	// value changed
+	agi, buf = mockAGIScript("200 result=0", "200 result=1", lock,
+		"200 result=1 (5)", lock, "200 result=1")
+	agi.env = map[string]string{"uniqueid": "1700.1"}
+	ok, err = NewStore(agi).CompareAndSwap("counter", "calls", 3, 4)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.NotContains(t, buf.String(), "PUT counter calls")
+
+	// nil old value requires missing key
+	agi, _ = mockAGIScript("200 result=0", "200 result=1", lock,
+		"200 result=0", "200 result=1", lock, "200 result=1")
+	agi.env = map[string]string{"uniqueid": "1700.1"}
+	ok, err = NewStore(agi).CompareAndSwap("counter", "calls", nil, 1)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// lock taken over by other session is not deleted
+	other := "200 result=1 (other;" +
+		strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10) + ")"
+	agi, buf = mockAGIScript("200 result=0", "200 result=1", lock,
+		"200 result=0", "200 result=1", other)
+	agi.env = map[string]string{"uniqueid": "1700.1"}
+	ok, err = NewStore(agi).CompareAndSwap("counter", "calls", nil, 1)
+	assert.True(t, ok) // value is written
+	assert.True(t, errors.Is(err, ErrStore))
+	assert.Contains(t, err.Error(), `lock of "counter" is lost`)
+	assert.NotContains(t, buf.String(), "DATABASE DEL")
+}
+
+func TestStoreLockTimeout(t *testing.T) {
+	lock := "200 result=1 (other;" +
+		strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10) + ")"
+	agi, _ := mockAGIScript(lock, lock, lock, lock)
+	agi.env = map[string]string{"uniqueid": "1700.1"}
+	store := NewStore(agi)
+	store.LockTimeout = time.Millisecond
+	ok, err := store.CompareAndSwap("counter", "calls", 3, 4)
+	assert.False(t, ok)
+	assert.True(t, errors.Is(err, ErrStore))
+
+	// stale lock is taken over
+	stale := "200 result=1 (other;" +
+		strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10) + ")"
+	own := "200 result=1 (1700.1;" +
+		strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10) + ")"
+	agi, _ = mockAGIScript(stale, "200 result=1", own, "200 result=0",
+		"200 result=1", own, "200 result=1")
+	agi.env = map[string]string{"uniqueid": "1700.1"}
+	ok, err = NewStore(agi).CompareAndSwap("counter", "calls", nil, 1)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,348 @@
+package goagi
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrTemplate template is invalid or argument is missing
+var ErrTemplate = newError("Template")
+
+// PluralRule returns index of the plural form for the number
+type PluralRule func(n int64) int
+
+// PluralEnglish two forms: one and other. Also used for German, Spanish etc.
+func PluralEnglish(n int64) int {
+	if n == 1 || n == -1 {
+		return 0
+	}
+	return 1
+}
+
+// PluralFrench two forms: zero and one use singular form
+func PluralFrench(n int64) int {
+	if n >= -1 && n <= 1 {
+		return 0
+	}
+	return 1
+}
+
+// PluralRussian three forms: one (1, 21), few (2-4, 22-24) and many (0, 5-20, 25)
+func PluralRussian(n int64) int {
+	if n < 0 {
+		n = -n
+	}
+	mod10, mod100 := n%10, n%100
+	switch {
+	case mod10 == 1 && mod100 != 11:
+		return 0
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return 1
+	}
+	return 2
+}
+
+// SetPluralRule sets plural rule for the language. Rules for English,
+// French, Russian and Ukrainian are set by default. Other languages use
+// English rule.
+func (c *Catalog) SetPluralRule(lang string, rule PluralRule) *Catalog {
+	if c.plurals == nil {
+		c.plurals = make(map[string]PluralRule)
+	}
+	c.plurals[lang] = rule
+	return c
+}
+
+// PluralRule returns plural rule of the language
+func (c *Catalog) PluralRule(lang string) PluralRule {
+	candidates := []string{lang}
+	if idx := strings.IndexAny(lang, "_-"); idx > 0 {
+		candidates = append(candidates, lang[:idx])
+	}
+	for _, l := range candidates {
+		if rule, ok := c.plurals[l]; ok {
+			return rule
+		}
+		switch l {
+		case "fr":
+			return PluralFrench
+		case "ru", "uk":
+			return PluralRussian
+		}
+	}
+	return PluralEnglish
+}
+
+// AddTemplate sets sentence template for the language
+func (c *Catalog) AddTemplate(id, lang, template string) *Catalog {
+	if c.Templates == nil {
+		c.Templates = make(map[string]map[string]string)
+	}
+	if c.Templates[id] == nil {
+		c.Templates[id] = make(map[string]string)
+	}
+	c.Templates[id][lang] = template
+	return c
+}
+
+type templateToken struct {
+	file   string
+	name   string
+	kind   string
+	option string
+	forms  []string
+}
+
+/*
+Template is a parsed sentence template. Template is a list of tokens
+separated by spaces:
+
+	vm-youhave              sound file
+	{name}                  argument said by its type: integers with SAY NUMBER,
+	                        Digits with SAY DIGITS, time.Time with SAY DATETIME,
+	                        other values with SAY ALPHA
+	{name:number}           SAY NUMBER, number can be given as string
+	{name:number:f}         SAY NUMBER with gender
+	{name:digits}           SAY DIGITS
+	{name:alpha}            SAY ALPHA
+	{name:date}             SAY DATE
+	{name:time}             SAY TIME
+	{name:datetime:HM}      SAY DATETIME with format
+	{name|dollar|dollars}   sound file selected by plural form of the number
+
+Example of template for English and Russian:
+
+	vm-youhave {count:number} {count|vm-message|vm-messages}
+	vm-youhave {count:number:n} {count|vm-soobshenie|vm-soobsheniya|vm-soobsheniy}
+*/
+type Template struct {
+	tokens []templateToken
+}
+
+// ParseTemplate parses template text
+func ParseTemplate(text string) (*Template, error) {
+	tmpl := &Template{}
+	for _, field := range strings.Fields(text) {
+		if field[0] != '{' {
+			if strings.ContainsAny(field, "{}") {
+				return nil, ErrTemplate.Msg("invalid token %q", field)
+			}
+			tmpl.tokens = append(tmpl.tokens, templateToken{file: field})
+			continue
+		}
+		if len(field) < 3 || field[len(field)-1] != '}' {
+			return nil, ErrTemplate.Msg("invalid placeholder %q", field)
+		}
+		tok, err := parsePlaceholder(field[1 : len(field)-1])
+		if err != nil {
+			return nil, err
+		}
+		tmpl.tokens = append(tmpl.tokens, tok)
+	}
+	return tmpl, nil
+}
+
+func parsePlaceholder(body string) (templateToken, error) {
+	if strings.Contains(body, "|") {
+		parts := strings.Split(body, "|")
+		for _, p := range parts {
+			if p == "" {
+				return templateToken{}, ErrTemplate.Msg("empty plural form in {%s}", body)
+			}
+		}
+		if len(parts) < 2 {
+			return templateToken{}, ErrTemplate.Msg("no plural forms in {%s}", body)
+		}
+		return templateToken{name: parts[0], kind: "plural", forms: parts[1:]}, nil
+	}
+
+	parts := strings.SplitN(body, ":", 3)
+	tok := templateToken{name: parts[0]}
+	if tok.name == "" {
+		return tok, ErrTemplate.Msg("empty name in {%s}", body)
+	}
+	if len(parts) > 1 {
+		tok.kind = parts[1]
+	}
+	if len(parts) > 2 {
+		tok.option = parts[2]
+	}
+	switch tok.kind {
+	case "", "number", "digits", "alpha", "date", "time", "datetime":
+	default:
+		return tok, ErrTemplate.Msg("unknown kind %q in {%s}", tok.kind, body)
+	}
+	return tok, nil
+}
+
+// Playlist expands template with arguments to playlist. Plural forms are
+// selected with the plural rule.
+func (t *Template) Playlist(args map[string]interface{}, rule PluralRule,
+	escape Digits,
+) (*Playlist, error) {
+	list := NewPlaylist(escape)
+	for _, tok := range t.tokens {
+		if tok.file != "" {
+			list.File(tok.file)
+			continue
+		}
+		val, ok := args[tok.name]
+		if !ok {
+			return nil, ErrTemplate.Msg("missing argument %q", tok.name)
+		}
+		if err := tok.expand(list, val, rule); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+func (tok templateToken) expand(list *Playlist, val interface{}, rule PluralRule) error {
+	kind := tok.kind
+	if kind == "" {
+		kind = kindOf(val)
+	}
+	switch kind {
+	case "plural":
+		n, ok := toInt64(val)
+		if !ok {
+			return ErrTemplate.Msg("argument %q is not a number: %v", tok.name, val)
+		}
+		idx := rule(n)
+		if idx >= len(tok.forms) {
+			idx = len(tok.forms) - 1
+		}
+		list.File(tok.forms[idx])
+	case "number":
+		n, ok := toInt64(val)
+		if !ok {
+			return ErrTemplate.Msg("argument %q is not a number: %v", tok.name, val)
+		}
+		num, gender := strconv.FormatInt(n, 10), tok.option
+		list.add(func(agi *AGI, escape string) (Response, error) {
+			return agi.SayNumberGender(num, escape, gender)
+		})
+	case "date", "time", "datetime":
+		tm, ok := val.(time.Time)
+		if !ok {
+			return ErrTemplate.Msg("argument %q is not a time: %v", tok.name, val)
+		}
+		switch kind {
+		case "date":
+			list.Date(tm)
+		case "time":
+			list.add(func(agi *AGI, escape string) (Response, error) {
+				return agi.SayTimeAt(tm, escape, nil)
+			})
+		default:
+			list.Datetime(tm, tok.option, nil)
+		}
+	case "digits":
+		list.Digits(toString(val))
+	default:
+		list.Alpha(toString(val))
+	}
+	return nil
+}
+
+func kindOf(val interface{}) string {
+	switch val.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return "number"
+	case Digits:
+		return "digits"
+	case time.Time:
+		return "datetime"
+	}
+	return "alpha"
+}
+
+func toInt64(val interface{}) (int64, bool) {
+	switch v := val.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		return n, err == nil
+	case Digits:
+		n, err := strconv.ParseInt(string(v), 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func toString(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case Digits:
+		return string(v)
+	}
+	if n, ok := toInt64(val); ok {
+		return strconv.FormatInt(n, 10)
+	}
+	return ""
+}
+
+// Template returns parsed template for the language using fallback chain
+// and plural rule of the template language
+func (c *Catalog) Template(id, lang string) (*Template, PluralRule, error) {
+	texts, ok := c.Templates[id]
+	if !ok {
+		return nil, nil, ErrTemplate.Msg("unknown template %q", id)
+	}
+	text, found, ok := c.lookup(texts, lang)
+	if !ok {
+		return nil, nil, ErrTemplate.Msg("template %q has no text for language %q", id, lang)
+	}
+	tmpl, err := ParseTemplate(text)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tmpl, c.PluralRule(found), nil
+}
+
+/*
+Announce plays sentence template from catalog in session language.
+
+Example:
+
+	catalog.AddTemplate("balance", "en",
+		"your-balance-is {amount:number} {amount|dollar|dollars} and {cents:number} {cents|cent|cents}")
+	res, err := agi.Announce("balance", map[string]interface{}{"amount": 12, "cents": 5}, goagi.NoDigits)
+*/
+func (agi *AGI) Announce(id string, args map[string]interface{},
+	escape Digits,
+) (*PlaylistResult, error) {
+	if agi.catalog == nil {
+		return nil, ErrTemplate.Msg("catalog is not set")
+	}
+	tmpl, rule, err := agi.catalog.Template(id, agi.Language())
+	if err != nil {
+		return nil, err
+	}
+	list, err := tmpl.Playlist(args, rule, escape)
+	if err != nil {
+		return nil, err
+	}
+	return list.Play(agi)
+}
@@ -0,0 +1,184 @@
+package goagi
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPluralRules(t *testing.T) {
+	en := map[int64]int{-2: 1, -1: 0, 0: 1, 1: 0, 2: 1, 5: 1, 21: 1}
+	for n, form := range en {
+		assert.Equal(t, form, PluralEnglish(n), n)
+	}
+
+	fr := map[int64]int{-2: 1, -1: 0, 0: 0, 1: 0, 2: 1, 5: 1, 21: 1}
+	for n, form := range fr {
+		assert.Equal(t, form, PluralFrench(n), n)
+	}
+
+	ru := map[int64]int{
+		0: 2, 1: 0, 2: 1, 4: 1, 5: 2, 11: 2, 12: 2, 14: 2, 20: 2,
+		21: 0, 22: 1, 25: 2, 101: 0, 111: 2, 112: 2, 122: 1, -3: 1,
+	}
+	for n, form := range ru {
+		assert.Equal(t, form, PluralRussian(n), n)
+	}
+}
+
+func TestCatalogPluralRule(t *testing.T) {
+	c := NewCatalog("en")
+	assert.Equal(t, 2, c.PluralRule("ru")(5))
+	assert.Equal(t, 2, c.PluralRule("uk")(5))
+	assert.Equal(t, 0, c.PluralRule("fr_CA")(0))
+	assert.Equal(t, 1, c.PluralRule("de")(0))
+
+	c.SetPluralRule("pl", func(n int64) int { return 7 })
+	assert.Equal(t, 7, c.PluralRule("pl_PL")(1))
+}
+
+func TestParseTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate("  vm-youhave {n:number:f}   {n|one|many} {d} {t:datetime:HM} ")
+	assert.Nil(t, err)
+	assert.Equal(t, []templateToken{
+		{file: "vm-youhave"},
+		{name: "n", kind: "number", option: "f"},
+		{name: "n", kind: "plural", forms: []string{"one", "many"}},
+		{name: "d"},
+		{name: "t", kind: "datetime", option: "HM"},
+	}, tmpl.tokens)
+
+	invalid := []string{
+		"foo{bar}", "{}", "{n", "{n|}", "{n|a||b}", "{n:foo}", "{:number}", "{n|}",
+	}
+	for _, text := range invalid {
+		_, err := ParseTemplate(text)
+		assert.True(t, errors.Is(err, ErrTemplate), text)
+	}
+}
+
+func TestTemplatePlaylist(t *testing.T) {
+	tm := time.Unix(1563844045, 0)
+	tmpl, err := ParseTemplate("start {n} {n|one|many} {s:number} {d} {a} {t} " +
+		"{t:date} {t:time} {t:datetime:HM} {x:digits} {x:alpha}")
+	assert.Nil(t, err)
+	list, err := tmpl.Playlist(map[string]interface{}{
+		"n": uint8(1),
+		"s": "42",
+		"d": Digits("123"),
+		"a": "abc",
+		"t": tm,
+		"x": 77,
+	}, PluralEnglish, "#")
+	assert.Nil(t, err)
+
+	responses := make([]string, list.Len())
+	for i := range responses {
+		responses[i] = "200 result=0"
+	}
+	agi, buf := mockAGIScript(responses...)
+	_, err = list.Play(agi)
+	assert.Nil(t, err)
+	assert.Equal(t, "STREAM FILE start \"#\" 0\n"+
+		"SAY NUMBER 1 \"#\"\n"+
+		"STREAM FILE one \"#\" 0\n"+
+		"SAY NUMBER 42 \"#\"\n"+
+		"SAY DIGITS 123 \"#\"\n"+
+		"SAY ALPHA abc \"#\"\n"+
+		"SAY DATETIME 1563844045 \"#\" \"\" \"\"\n"+
+		"SAY DATE 1563844045 \"#\"\n"+
+		"SAY TIME 1563844045 \"#\"\n"+
+		"SAY DATETIME 1563844045 \"#\" \"HM\" \"\"\n"+
+		"SAY DIGITS 77 \"#\"\n"+
+		"SAY ALPHA 77 \"#\"\n", buf.String())
+}
+
+func TestTemplatePlaylistFail(t *testing.T) {
+	tests := []struct {
+		text string
+		args map[string]interface{}
+	}{
+		{"{n}", map[string]interface{}{}},
+		{"{n:number}", map[string]interface{}{"n": "abc"}},
+		{"{n|a|b}", map[string]interface{}{"n": 1.5}},
+		{"{n:date}", map[string]interface{}{"n": 1}},
+	}
+	for _, tc := range tests {
+		tmpl, err := ParseTemplate(tc.text)
+		assert.Nil(t, err)
+		_, err = tmpl.Playlist(tc.args, PluralEnglish, "")
+		assert.True(t, errors.Is(err, ErrTemplate), tc.text)
+	}
+
+	// plural form index is limited by number of forms
+	tmpl, _ := ParseTemplate("{n|one|many}")
+	list, err := tmpl.Playlist(map[string]interface{}{"n": 5}, PluralRussian, "")
+	assert.Nil(t, err)
+	agi, buf := mockAGIScript("200 result=0")
+	_, _ = list.Play(agi)
+	assert.Equal(t, "STREAM FILE many \"\" 0\n", buf.String())
+}
+
+func TestAnnounce(t *testing.T) {
+	c := NewCatalog("en").
+		AddTemplate("balance", "en",
+			"your-balance-is {amount:number} {amount|dollar|dollars} and {cents:number} {cents|cent|cents}").
+		AddTemplate("balance", "fr",
+			"votre-solde-est {amount:number} {amount|dollar|dollars} et {cents:number} {cents|cent|cents}").
+		AddTemplate("balance", "ru",
+			"vash-balans {amount:number:m} {amount|dollar|dollara|dollarov} {cents:number:m} {cents|cent|centa|centov}")
+	args := map[string]interface{}{"amount": 1, "cents": 0}
+
+	tests := []struct {
+		lang string
+		cmds string
+	}{
+		{"en_US", "STREAM FILE your-balance-is \"\" 0\nSAY NUMBER 1 \"\"\nSTREAM FILE dollar \"\" 0\n" +
+			"STREAM FILE and \"\" 0\nSAY NUMBER 0 \"\"\nSTREAM FILE cents \"\" 0\n"},
+		{"fr", "STREAM FILE votre-solde-est \"\" 0\nSAY NUMBER 1 \"\"\nSTREAM FILE dollar \"\" 0\n" +
+			"STREAM FILE et \"\" 0\nSAY NUMBER 0 \"\"\nSTREAM FILE cent \"\" 0\n"},
+		{"ru", "STREAM FILE vash-balans \"\" 0\nSAY NUMBER 1 \"\" m\nSTREAM FILE dollar \"\" 0\n" +
+			"SAY NUMBER 0 \"\" m\nSTREAM FILE centov \"\" 0\n"},
+	}
+	for _, tc := range tests {
+		agi, buf := mockAGIScript("200 result=0", "200 result=0", "200 result=0",
+			"200 result=0", "200 result=0", "200 result=0")
+		agi.catalog = c
+		agi.SetLanguage(tc.lang)
+		res, err := agi.Announce("balance", args, NoDigits)
+		assert.Nil(t, err, tc.lang)
+		assert.Equal(t, StopFinished, res.Reason)
+		assert.Equal(t, tc.cmds, buf.String(), tc.lang)
+	}
+}
+
+func TestAnnounceFail(t *testing.T) {
+	agi, _ := mockAGI(respOk)
+	_, err := agi.Announce("foo", nil, "")
+	assert.True(t, errors.Is(err, ErrTemplate))
+	assert.Contains(t, err.Error(), "catalog is not set")
+
+	agi.catalog = NewCatalog().AddTemplate("foo", "fr", "{n").AddTemplate("bar", "en", "{n}")
+	agi.SetLanguage("en")
+	_, err = agi.Announce("baz", nil, "")
+	assert.Contains(t, err.Error(), `unknown template "baz"`)
+	_, err = agi.Announce("foo", nil, "")
+	assert.Contains(t, err.Error(), `template "foo" has no text for language "en"`)
+	agi.SetLanguage("fr")
+	_, err = agi.Announce("foo", nil, "")
+	assert.Contains(t, err.Error(), `invalid placeholder "{n"`)
+	agi.SetLanguage("en")
+	_, err = agi.Announce("bar", nil, "")
+	assert.Contains(t, err.Error(), `missing argument "n"`)
+}
+
+func TestCatalogTemplatesJSON(t *testing.T) {
+	c, err := ParseCatalog([]byte(`{"fallback":["en"],"templates":{"hi":{"en":"hello {name:alpha}"}}}`))
+	assert.Nil(t, err)
+	tmpl, rule, err := c.Template("hi", "de")
+	assert.Nil(t, err)
+	assert.NotNil(t, rule)
+	assert.Equal(t, 2, len(tmpl.tokens))
+}
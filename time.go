@@ -0,0 +1,181 @@
+package goagi
+
+import (
+	"fmt"
+	"time"
+)
+
+// Timeout sentinels accepted by commands with time.Duration arguments.
+// Each command documents how sentinels are converted to AGI values.
+const (
+	// TimeoutDefault lets Asterisk use the command default timeout
+	TimeoutDefault time.Duration = 0
+	// TimeoutInfinite blocks until input is received or channel hangs up
+	TimeoutInfinite time.Duration = -1
+)
+
+// Say date and time formats used when SAY DATE and SAY TIME commands are
+// emulated with SAY DATETIME to pass timezone.
+const (
+	sayDateFormat = "ABdY"
+	sayTimeFormat = "IMp"
+)
+
+// durationMs converts positive duration to milliseconds. Durations shorter
+// than a millisecond are rounded up to not be confused with sentinels.
+func durationMs(d time.Duration) int {
+	ms := d.Milliseconds()
+	if ms == 0 && d > 0 {
+		return 1
+	}
+	return int(ms)
+}
+
+// durationSec converts positive duration to seconds rounded up
+func durationSec(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// locationName returns timezone name for SAY DATETIME. Empty string is
+// returned for nil or local location so Asterisk uses its own timezone.
+func locationName(loc *time.Location) string {
+	if loc == nil || loc == time.Local {
+		return ""
+	}
+	return loc.String()
+}
+
+/*
+WaitForDigitTimeout is WaitForDigit with timeout as time.Duration.
+
+TimeoutInfinite blocks until digit is received. TimeoutDefault returns
+immediately if no digit is in the buffer.
+*/
+func (agi *AGI) WaitForDigitTimeout(timeout time.Duration) (Response, error) {
+	if timeout < 0 {
+		return agi.WaitForDigit(-1)
+	}
+	return agi.WaitForDigit(durationMs(timeout))
+}
+
+/*
+GetDataTimeout is GetData with timeout as time.Duration.
+
+TimeoutDefault makes Asterisk use its default timeout (6 seconds).
+TimeoutInfinite is not supported by GET DATA and returns error.
+*/
+func (agi *AGI) GetDataTimeout(file string, timeout time.Duration, maxdigit int) (Response, error) {
+	if timeout < 0 {
+		return nil, ErrAGI.Msg("GetData does not support infinite timeout")
+	}
+	return agi.GetData(file, durationMs(timeout), maxdigit)
+}
+
+/*
+GetOptionTimeout is GetOption with timeout as time.Duration.
+
+TimeoutDefault omits timeout argument and Asterisk uses dialplan digit
+timeout (5 seconds by default). TimeoutInfinite is not supported by GET OPTION
+and returns error.
+*/
+func (agi *AGI) GetOptionTimeout(filename, digits string, timeout time.Duration) (Response, error) {
+	if timeout < 0 {
+		return nil, ErrAGI.Msg("GetOption does not support infinite timeout")
+	}
+	if timeout == TimeoutDefault {
+		if err := validDigits(digits); err != nil {
+			return nil, err
+		}
+		cmd := fmt.Sprintf("GET OPTION %s %q\n", filename, digits)
+		return agi.execute(cmd)
+	}
+	return agi.GetOption(filename, digits, int32(durationMs(timeout)))
+}
+
+/*
+ReceiveCharTimeout is ReceiveChar with timeout as time.Duration.
+
+Both TimeoutDefault and TimeoutInfinite wait for character infinitely.
+*/
+func (agi *AGI) ReceiveCharTimeout(timeout time.Duration) (Response, error) {
+	if timeout < 0 {
+		timeout = 0
+	}
+	return agi.ReceiveChar(durationMs(timeout))
+}
+
+/*
+ReceiveTextTimeout is ReceiveText with timeout as time.Duration.
+
+Both TimeoutDefault and TimeoutInfinite wait for text infinitely.
+*/
+func (agi *AGI) ReceiveTextTimeout(timeout time.Duration) (Response, error) {
+	if timeout < 0 {
+		timeout = 0
+	}
+	return agi.ReceiveText(durationMs(timeout))
+}
+
+/*
+RecordFileTimeout is RecordFile with maximum record time and silence as
+time.Duration. Silence is rounded up to seconds.
+
+Both TimeoutDefault and TimeoutInfinite record without time limit.
+Zero silence disables silence detection.
+*/
+func (agi *AGI) RecordFileTimeout(file, format, escDigits string,
+	timeout time.Duration, offset int, beep bool, silence time.Duration,
+) (Response, error) {
+	ms := -1
+	if timeout > 0 {
+		ms = durationMs(timeout)
+	}
+	return agi.RecordFile(file, format, escDigits, ms, offset, beep, durationSec(silence))
+}
+
+/*
+SayDateAt says date of the given time, returning early if any of the given
+DTMF digits are received on the channel.
+
+When location is nil then date is said in Asterisk timezone with SAY DATE
+command. Otherwise SAY DATETIME is used with date format and timezone name.
+*/
+func (agi *AGI) SayDateAt(t time.Time, escDigits string, loc *time.Location) (Response, error) {
+	if loc == nil {
+		return agi.SayDate(fmt.Sprintf("%d", t.Unix()), escDigits)
+	}
+	return agi.SayDatetimeAt(t, escDigits, sayDateFormat, loc)
+}
+
+/*
+SayTimeAt says time of the given time, returning early if any of the given
+DTMF digits are received on the channel.
+
+When location is nil then time is said in Asterisk timezone with SAY TIME
+command. Otherwise SAY DATETIME is used with time format and timezone name.
+*/
+func (agi *AGI) SayTimeAt(t time.Time, escDigits string, loc *time.Location) (Response, error) {
+	if loc == nil {
+		return agi.SayTime(fmt.Sprintf("%d", t.Unix()), escDigits)
+	}
+	return agi.SayDatetimeAt(t, escDigits, sayTimeFormat, loc)
+}
+
+/*
+SayDatetimeAt says the given time with format, returning early if any of
+the given DTMF digits are received on the channel.
+
+Format is Asterisk say format (see voicemail.conf), empty for default.
+Timezone name is taken from location. As with SayDateAt and SayTimeAt,
+nil location means Asterisk timezone; use t.Location() to say time in
+its own timezone. Local timezone is also sent as empty so Asterisk uses
+its own.
+*/
+func (agi *AGI) SayDatetimeAt(t time.Time, escDigits, format string,
+	loc *time.Location,
+) (Response, error) {
+	return agi.SayDatetime(fmt.Sprintf("%d", t.Unix()), escDigits, format, locationName(loc))
+}
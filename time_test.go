@@ -0,0 +1,138 @@
+package goagi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDurationConvert(t *testing.T) {
+	assert.Equal(t, 0, durationMs(0))
+	assert.Equal(t, 1, durationMs(time.Microsecond))
+	assert.Equal(t, 1500, durationMs(1500*time.Millisecond))
+	assert.Equal(t, 0, durationSec(-1))
+	assert.Equal(t, 0, durationSec(0))
+	assert.Equal(t, 1, durationSec(time.Millisecond))
+	assert.Equal(t, 3, durationSec(3*time.Second))
+	assert.Equal(t, 4, durationSec(3*time.Second+time.Millisecond))
+}
+
+func TestCmdTimeoutCommands(t *testing.T) {
+	tests := []struct {
+		call func(agi *AGI) (Response, error)
+		cmd  string
+	}{
+		{
+			func(agi *AGI) (Response, error) { return agi.WaitForDigitTimeout(TimeoutInfinite) },
+			"WAIT FOR DIGIT -1\n",
+		}, {
+			func(agi *AGI) (Response, error) { return agi.WaitForDigitTimeout(2 * time.Second) },
+			"WAIT FOR DIGIT 2000\n",
+		}, {
+			func(agi *AGI) (Response, error) { return agi.GetDataTimeout("menu", TimeoutDefault, 3) },
+			"GET DATA menu 0 3\n",
+		}, {
+			func(agi *AGI) (Response, error) { return agi.GetDataTimeout("menu", 5*time.Second, 3) },
+			"GET DATA menu 5000 3\n",
+		}, {
+			func(agi *AGI) (Response, error) { return agi.GetOptionTimeout("menu", "12", TimeoutDefault) },
+			"GET OPTION menu \"12\"\n",
+		}, {
+			func(agi *AGI) (Response, error) { return agi.GetOptionTimeout("menu", "12", time.Second) },
+			"GET OPTION menu \"12\" 1000\n",
+		}, {
+			func(agi *AGI) (Response, error) { return agi.ReceiveCharTimeout(TimeoutInfinite) },
+			"RECEIVE CHAR 0\n",
+		}, {
+			func(agi *AGI) (Response, error) { return agi.ReceiveCharTimeout(250 * time.Millisecond) },
+			"RECEIVE CHAR 250\n",
+		}, {
+			func(agi *AGI) (Response, error) { return agi.ReceiveTextTimeout(TimeoutInfinite) },
+			"RECEIVE TEXT 0\n",
+		}, {
+			func(agi *AGI) (Response, error) { return agi.ReceiveTextTimeout(time.Minute) },
+			"RECEIVE TEXT 60000\n",
+		}, {
+			func(agi *AGI) (Response, error) {
+				return agi.RecordFileTimeout("rec", "wav", "#", TimeoutInfinite, 0, false, 0)
+			},
+			"RECORD FILE rec wav \"#\" -1\n",
+		}, {
+			func(agi *AGI) (Response, error) {
+				return agi.RecordFileTimeout("rec", "wav", "#", time.Minute, 0, true,
+					2500*time.Millisecond)
+			},
+			"RECORD FILE rec wav \"#\" 60000 BEEP s=3\n",
+		},
+	}
+
+	for _, tc := range tests {
+		agi, buf := mockAGI("200 result=0")
+		resp, err := tc.call(agi)
+		assert.Nil(t, err, tc.cmd)
+		assert.Equal(t, 200, resp.Code())
+		assert.Equal(t, tc.cmd, buf.String())
+	}
+}
+
+func TestCmdTimeoutInfiniteNotSupported(t *testing.T) {
+	agi, buf := mockAGI(respOk)
+	_, err := agi.GetDataTimeout("menu", TimeoutInfinite, 1)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "infinite")
+
+	_, err = agi.GetOptionTimeout("menu", "", TimeoutInfinite)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "infinite")
+	assert.Empty(t, buf.String())
+}
+
+func TestCmdSayTimeAt(t *testing.T) {
+	montreal, err := time.LoadLocation("America/Montreal")
+	if err != nil {
+		t.Skip("timezone database is not available")
+	}
+	tm := time.Unix(1563844045, 0).In(time.UTC)
+
+	tests := []struct {
+		call func(agi *AGI) (Response, error)
+		cmd  string
+	}{
+		{
+			func(agi *AGI) (Response, error) { return agi.SayDateAt(tm, "", nil) },
+			"SAY DATE 1563844045 \"\"\n",
+		}, {
+			func(agi *AGI) (Response, error) { return agi.SayDateAt(tm, "#", montreal) },
+			"SAY DATETIME 1563844045 \"#\" \"ABdY\" \"America/Montreal\"\n",
+		}, {
+			func(agi *AGI) (Response, error) { return agi.SayTimeAt(tm, "0", nil) },
+			"SAY TIME 1563844045 \"0\"\n",
+		}, {
+			func(agi *AGI) (Response, error) { return agi.SayTimeAt(tm, "", time.UTC) },
+			"SAY DATETIME 1563844045 \"\" \"IMp\" \"UTC\"\n",
+		}, {
+			func(agi *AGI) (Response, error) { return agi.SayDatetimeAt(tm, "", "dB", nil) },
+			"SAY DATETIME 1563844045 \"\" \"dB\" \"\"\n",
+		}, {
+			func(agi *AGI) (Response, error) { return agi.SayDatetimeAt(tm, "", "dB", tm.Location()) },
+			"SAY DATETIME 1563844045 \"\" \"dB\" \"UTC\"\n",
+		}, {
+			func(agi *AGI) (Response, error) {
+				return agi.SayDatetimeAt(tm, "*", "", time.Local)
+			},
+			"SAY DATETIME 1563844045 \"*\" \"\" \"\"\n",
+		}, {
+			func(agi *AGI) (Response, error) { return agi.SayDatetimeAt(tm, "", "", montreal) },
+			"SAY DATETIME 1563844045 \"\" \"\" \"America/Montreal\"\n",
+		},
+	}
+
+	for _, tc := range tests {
+		agi, buf := mockAGI(respOk)
+		resp, err := tc.call(agi)
+		assert.Nil(t, err, tc.cmd)
+		assert.Equal(t, 1, resp.Result())
+		assert.Equal(t, tc.cmd, buf.String())
+	}
+}
@@ -0,0 +1,162 @@
+package goagi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrTTS text-to-speech rendering error
+var ErrTTS = newError("TTS")
+
+// TTSEngine renders text to audio
+type TTSEngine interface {
+	// Render writes audio of the text in language to writer
+	Render(text, lang string, w io.Writer) error
+	// Format returns audio file format supported by Asterisk: "wav", "sln", "gsm" etc
+	Format() string
+}
+
+/*
+Speaker renders text with TTS engine to sound files in directory and plays
+them on the channel. Rendered files are cached by text and language hash,
+so the same text is rendered once.
+
+Directory must be readable by Asterisk. For FastAGI server on another host
+it should be shared with Asterisk.
+
+Example:
+
+	speaker := goagi.NewSpeaker(engine, "/var/lib/asterisk/sounds/tts")
+	agi, err := goagi.New(conn, conn, nil, goagi.WithSpeaker(speaker))
+	...
+	res, err := agi.Speak("Hello, John Smith", goagi.AllDigits)
+*/
+type Speaker struct {
+	engine TTSEngine
+	dir    string
+}
+
+// NewSpeaker creates speaker with TTS engine and sounds directory
+func NewSpeaker(engine TTSEngine, dir string) *Speaker {
+	return &Speaker{engine: engine, dir: dir}
+}
+
+// WithSpeaker sets speaker used by Speak commands
+func WithSpeaker(s *Speaker) Option {
+	return func(agi *AGI) { agi.speaker = s }
+}
+
+// cacheName returns file name without extension for text and language
+func (s *Speaker) cacheName(text, lang string) string {
+	sum := sha256.Sum256([]byte(lang + "\x00" + text))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
+
+/*
+File renders text to sound file if it is not in cache and returns
+file path without extension as it is used by STREAM FILE command.
+*/
+func (s *Speaker) File(text, lang string) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return "", ErrTTS.Msg("empty text")
+	}
+	name := s.cacheName(text, lang)
+	path := name + "." + s.engine.Format()
+	if _, err := os.Stat(path); err == nil {
+		return name, nil
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(s.dir, ".tts-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := s.engine.Render(text, lang, tmp); err != nil {
+		tmp.Close()
+		return "", ErrTTS.Msg("render failed: %s", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	// files are read by Asterisk that often runs as other user
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+func (agi *AGI) speakFile(text string) (string, error) {
+	if agi.speaker == nil {
+		return "", ErrTTS.Msg("speaker is not set")
+	}
+	return agi.speaker.File(text, agi.Language())
+}
+
+// Speak renders text in session language and plays it with StreamFile
+func (agi *AGI) Speak(text string, escape Digits) (*MediaResult, error) {
+	file, err := agi.speakFile(text)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := checkResponse(agi.StreamFile(file, string(escape), 0))
+	if err != nil {
+		return nil, err
+	}
+	return newStreamResult(resp), nil
+}
+
+// SpeakControl renders text in session language and plays it with
+// ControlStreamFileOpts
+func (agi *AGI) SpeakControl(text string, opts ControlStreamOptions) (*MediaResult, error) {
+	file, err := agi.speakFile(text)
+	if err != nil {
+		return nil, err
+	}
+	return agi.ControlStreamFileOpts(file, opts)
+}
+
+/*
+SilentEngine is TTS engine that renders silence in signed linear 8kHz
+format. Length of silence is proportional to text length. It is useful
+for tests.
+*/
+type SilentEngine struct {
+	// PerChar length of silence per text character. 50ms if zero
+	PerChar time.Duration
+}
+
+// Format returns "sln" format
+func (e SilentEngine) Format() string { return "sln" }
+
+// Render writes silence to writer
+func (e SilentEngine) Render(text, lang string, w io.Writer) error {
+	perChar := e.PerChar
+	if perChar == 0 {
+		perChar = 50 * time.Millisecond
+	}
+	// 8000 samples per second, 2 bytes per sample
+	size := int64(len(text)) * int64(perChar) * 16000 / int64(time.Second)
+	_, err := io.CopyN(w, zeroReader{}, size)
+	return err
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = 0
+	}
+	return len(b), nil
+}
@@ -0,0 +1,124 @@
+package goagi
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingEngine struct {
+	SilentEngine
+	calls int
+	fail  error
+}
+
+func (e *countingEngine) Render(text, lang string, w io.Writer) error {
+	e.calls++
+	if e.fail != nil {
+		return e.fail
+	}
+	return e.SilentEngine.Render(text, lang, w)
+}
+
+func TestSilentEngine(t *testing.T) {
+	tmp, err := os.CreateTemp(t.TempDir(), "silence")
+	assert.Nil(t, err)
+	defer tmp.Close()
+
+	engine := SilentEngine{PerChar: 100 * time.Millisecond}
+	assert.Equal(t, "sln", engine.Format())
+	assert.Nil(t, engine.Render("hello", "en", tmp))
+	info, _ := tmp.Stat()
+	assert.EqualValues(t, 8000, info.Size())
+
+	assert.Nil(t, SilentEngine{}.Render("hi", "en", io.Discard))
+}
+
+func TestSpeakerFileCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tts")
+	engine := &countingEngine{}
+	speaker := NewSpeaker(engine, dir)
+
+	file, err := speaker.File("Hello John", "en")
+	assert.Nil(t, err)
+	assert.Equal(t, dir, filepath.Dir(file))
+	info, err := os.Stat(file + ".sln")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 8000, info.Size())
+	assert.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+
+	cached, err := speaker.File("Hello John", "en")
+	assert.Nil(t, err)
+	assert.Equal(t, file, cached)
+	assert.Equal(t, 1, engine.calls)
+
+	other, err := speaker.File("Hello John", "fr")
+	assert.Nil(t, err)
+	assert.NotEqual(t, file, other)
+	assert.Equal(t, 2, engine.calls)
+
+	entries, _ := os.ReadDir(dir)
+	assert.Equal(t, 2, len(entries))
+}
+
+func TestSpeakerFileFail(t *testing.T) {
+	dir := t.TempDir()
+	engine := &countingEngine{fail: errors.New("engine is down")}
+	speaker := NewSpeaker(engine, dir)
+
+	_, err := speaker.File("  ", "en")
+	assert.True(t, errors.Is(err, ErrTTS))
+	assert.Equal(t, 0, engine.calls)
+
+	_, err = speaker.File("Hello", "en")
+	assert.True(t, errors.Is(err, ErrTTS))
+	assert.Contains(t, err.Error(), "engine is down")
+	entries, _ := os.ReadDir(dir)
+	assert.Empty(t, entries)
+
+	// directory can not be created under regular file
+	blocker := filepath.Join(dir, "file")
+	assert.Nil(t, os.WriteFile(blocker, nil, 0o600))
+	_, err = NewSpeaker(SilentEngine{}, filepath.Join(blocker, "tts")).File("Hello", "en")
+	assert.NotNil(t, err)
+}
+
+func TestAGISpeak(t *testing.T) {
+	dir := t.TempDir()
+	speaker := NewSpeaker(SilentEngine{}, dir)
+	file, _ := speaker.File("Hello", "en")
+
+	agi, buf := mockAGI("200 result=35 endpos=1200")
+	_, err := agi.Speak("Hello", AllDigits)
+	assert.True(t, errors.Is(err, ErrTTS))
+	assert.Contains(t, err.Error(), "speaker is not set")
+
+	WithSpeaker(speaker)(agi)
+	agi.SetLanguage("en")
+	res, err := agi.Speak("Hello", "#")
+	assert.Nil(t, err)
+	assert.Equal(t, StopDTMF, res.Reason)
+	assert.Equal(t, "#", res.Digit)
+	assert.EqualValues(t, 1200, res.EndPos)
+	assert.Equal(t, "STREAM FILE "+file+" \"#\" 0\n", buf.String())
+
+	agi, buf = mockAGI("200 result=0 endpos=1200")
+	agi.speaker = speaker
+	agi.SetLanguage("en")
+	res, err = agi.SpeakControl("Hello", ControlStreamOptions{Stop: "#", Pause: "5"})
+	assert.Nil(t, err)
+	assert.Equal(t, StopFinished, res.Reason)
+	assert.Equal(t, "CONTROL STREAM FILE "+file+" \"#\" \"\" \"\" \"\" \"5\"\n", buf.String())
+
+	agi, _ = mockAGI("511 Command Not Permitted on a dead channel or intercept routine")
+	agi.speaker = speaker
+	_, err = agi.Speak("Hello", "")
+	assert.True(t, errors.Is(err, ErrCommand))
+	_, err = agi.SpeakControl("", ControlStreamOptions{})
+	assert.True(t, errors.Is(err, ErrTTS))
+}
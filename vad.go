@@ -0,0 +1,149 @@
+package goagi
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// EAGI audio stream format: signed linear 16-bit little-endian mono 8kHz
+const (
+	// AudioSampleRate EAGI audio sample rate
+	AudioSampleRate = 8000
+	// AudioFrameSize size in bytes of 20ms audio frame
+	AudioFrameSize = AudioSampleRate / 50 * 2
+)
+
+// Default voice activity detection parameters
+const (
+	DefaultVADThreshold  = 500
+	DefaultVADMinSpeech  = 100 * time.Millisecond
+	DefaultVADMinSilence = 800 * time.Millisecond
+)
+
+// VADEventType type of voice activity event
+type VADEventType int
+
+// Voice activity events
+const (
+	// VADNone no changes in voice activity
+	VADNone VADEventType = iota
+	// VADSpeechStart speech started
+	VADSpeechStart
+	// VADSpeechEnd speech ended after silence
+	VADSpeechEnd
+)
+
+// String returns event type name
+func (t VADEventType) String() string {
+	switch t {
+	case VADNone:
+		return "none"
+	case VADSpeechStart:
+		return "speech-start"
+	case VADSpeechEnd:
+		return "speech-end"
+	}
+	return "unknown"
+}
+
+// VADEvent voice activity event with time offset from the beginning of
+// the processed audio
+type VADEvent struct {
+	Type VADEventType
+	At   time.Duration
+}
+
+/*
+VAD is energy-based voice activity detector for signed linear 16-bit
+little-endian 8kHz audio. Speech starts when frames energy is above
+threshold for MinSpeech and ends when it is below threshold for MinSilence.
+Zero values are replaced with defaults.
+*/
+type VAD struct {
+	// Threshold of RMS frame energy (0-32768)
+	Threshold float64
+	// MinSpeech duration of energy above threshold to detect speech start
+	MinSpeech time.Duration
+	// MinSilence duration of energy below threshold to detect speech end
+	MinSilence time.Duration
+
+	speaking bool
+	samples  int64
+	run      int64
+}
+
+// Speaking returns true if speech is in progress
+func (v *VAD) Speaking() bool { return v.speaking }
+
+// Position returns duration of processed audio
+func (v *VAD) Position() time.Duration { return samplesDuration(v.samples) }
+
+// Reset resets detector state and position
+func (v *VAD) Reset() {
+	v.speaking = false
+	v.samples = 0
+	v.run = 0
+}
+
+// Process processes audio frame and returns voice activity event.
+// Event time is a time of the first frame of speech or silence run.
+func (v *VAD) Process(frame []byte) VADEvent {
+	n := int64(len(frame) / 2)
+	v.samples += n
+	voiced := FrameEnergy(frame) >= orDefault(v.Threshold, DefaultVADThreshold)
+
+	if voiced == v.speaking {
+		v.run = 0
+		return VADEvent{Type: VADNone}
+	}
+	v.run += n
+
+	limit := orDefaultDuration(v.MinSpeech, DefaultVADMinSpeech)
+	if v.speaking {
+		limit = orDefaultDuration(v.MinSilence, DefaultVADMinSilence)
+	}
+	if samplesDuration(v.run) < limit {
+		return VADEvent{Type: VADNone}
+	}
+
+	at := samplesDuration(v.samples - v.run)
+	v.run = 0
+	v.speaking = voiced
+	if voiced {
+		return VADEvent{Type: VADSpeechStart, At: at}
+	}
+	return VADEvent{Type: VADSpeechEnd, At: at}
+}
+
+// FrameEnergy returns RMS energy of signed linear 16-bit little-endian frame
+func FrameEnergy(frame []byte) float64 {
+	n := len(frame) / 2
+	if n == 0 {
+		return 0
+	}
+	var sum float64
+	for i := 0; i < n; i++ {
+		s := float64(int16(binary.LittleEndian.Uint16(frame[i*2:])))
+		sum += s * s
+	}
+	return math.Sqrt(sum / float64(n))
+}
+
+func samplesDuration(n int64) time.Duration {
+	return time.Duration(n) * time.Second / AudioSampleRate
+}
+
+func orDefault(v, def float64) float64 {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+func orDefaultDuration(v, def time.Duration) time.Duration {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
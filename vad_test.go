@@ -0,0 +1,73 @@
+package goagi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testAudio generates signed linear audio: amplitude for duration pairs
+func testAudio(parts ...interface{}) []byte {
+	buf := new(bytes.Buffer)
+	for i := 0; i+1 < len(parts); i += 2 {
+		amp := int16(parts[i].(int))
+		samples := int(parts[i+1].(time.Duration) * AudioSampleRate / time.Second)
+		for n := 0; n < samples; n++ {
+			s := amp
+			if n%2 == 1 {
+				s = -amp
+			}
+			_ = binary.Write(buf, binary.LittleEndian, s)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestFrameEnergy(t *testing.T) {
+	assert.Equal(t, float64(0), FrameEnergy(nil))
+	assert.Equal(t, float64(0), FrameEnergy(testAudio(0, 20*time.Millisecond)))
+	assert.InDelta(t, 1000, FrameEnergy(testAudio(1000, 20*time.Millisecond)), 0.001)
+}
+
+func TestVADEventTypeString(t *testing.T) {
+	assert.Equal(t, "none", VADNone.String())
+	assert.Equal(t, "speech-start", VADSpeechStart.String())
+	assert.Equal(t, "speech-end", VADSpeechEnd.String())
+	assert.Equal(t, "unknown", VADEventType(5).String())
+}
+
+func TestVADProcess(t *testing.T) {
+	audio := testAudio(
+		0, 200*time.Millisecond,
+		3000, 40*time.Millisecond, // too short to be speech
+		0, 100*time.Millisecond,
+		3000, 1*time.Second,
+		0, 300*time.Millisecond, // too short to end speech
+		3000, 200*time.Millisecond,
+		0, 1*time.Second,
+	)
+	vad := &VAD{}
+	events := make([]VADEvent, 0)
+	for i := 0; i+AudioFrameSize <= len(audio); i += AudioFrameSize {
+		if ev := vad.Process(audio[i : i+AudioFrameSize]); ev.Type != VADNone {
+			events = append(events, ev)
+		}
+	}
+	assert.Equal(t, []VADEvent{
+		{VADSpeechStart, 340 * time.Millisecond},
+		{VADSpeechEnd, 1840 * time.Millisecond},
+	}, events)
+	assert.False(t, vad.Speaking())
+	assert.Equal(t, 2840*time.Millisecond, vad.Position())
+
+	vad.Reset()
+	assert.Equal(t, time.Duration(0), vad.Position())
+
+	vad = &VAD{Threshold: 5000, MinSpeech: 20 * time.Millisecond}
+	assert.Equal(t, VADNone, vad.Process(testAudio(3000, 20*time.Millisecond)).Type)
+	assert.Equal(t, VADSpeechStart, vad.Process(testAudio(6000, 20*time.Millisecond)).Type)
+	assert.True(t, vad.Speaking())
+}
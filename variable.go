@@ -0,0 +1,332 @@
+package goagi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrVariable error returned when channel variable can not be converted
+var ErrVariable = newError("Channel variable")
+
+// VarType types supported by typed variable helpers GetVar and SetVar
+type VarType interface {
+	string | int | int64 | uint | float64 | bool | time.Time | time.Duration
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+/*
+Variable returns channel variable value and true if variable is set.
+Unlike GetVariable, unset variable (result=0) is not confused with
+variable set to empty string.
+*/
+func (agi *AGI) Variable(name string) (string, bool, error) {
+	resp, err := checkResponse(agi.GetVariable(name))
+	if err != nil {
+		return "", false, err
+	}
+	if resp.Result() != 1 {
+		return "", false, nil
+	}
+	return resp.Value(), true, nil
+}
+
+/*
+GetVar returns channel variable converted to type T. When variable is not
+set, returns zero value and false. Conversion failure returns ErrVariable.
+
+Boolean values accept Asterisk true/false strings ("yes", "on", "1" etc).
+Time is parsed as RFC3339 or unix epoch seconds. Duration is parsed as
+seconds ("1.5") or Go duration ("1m30s").
+
+	retries, ok, err := goagi.GetVar[int](agi, "RETRIES")
+*/
+func GetVar[T VarType](agi *AGI, name string) (T, bool, error) {
+	var v T
+	val, ok, err := agi.Variable(name)
+	if err != nil || !ok {
+		return v, false, err
+	}
+	if err := decodeVariable(reflect.ValueOf(&v).Elem(), name, val); err != nil {
+		return v, true, err
+	}
+	return v, true, nil
+}
+
+/*
+SetVar sets channel variable from value of type T. Booleans are set as
+"1" or "0", time as RFC3339 and duration as seconds, so values can be
+used in dialplan expressions.
+*/
+func SetVar[T VarType](agi *AGI, name string, value T) error {
+	val, err := encodeValue(reflect.ValueOf(value))
+	if err != nil {
+		return err
+	}
+	_, err = checkResponse(agi.SetVariable(name, val))
+	return err
+}
+
+/*
+SetVariables sets multiple channel variables. AGI protocol sets one
+variable per command, so variables are set in names order and the first
+failure stops setting.
+*/
+func (agi *AGI) SetVariables(vars map[string]string) error {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := checkResponse(agi.SetVariable(name, vars[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetVariables returns values of channel variables. Unset variables
+// are not included in the map.
+func (agi *AGI) GetVariables(names ...string) (map[string]string, error) {
+	vars := make(map[string]string, len(names))
+	for _, name := range names {
+		val, ok, err := agi.Variable(name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			vars[name] = val
+		}
+	}
+	return vars, nil
+}
+
+/*
+EncodeVariables converts struct fields to channel variables with prefix.
+Variable name is the prefix followed by field name or "agi" tag value.
+Fields with tag "-" and unexported fields are skipped.
+
+	type Caller struct {
+		Account string `agi:"ACCOUNT"`
+		Tries   int    `agi:"TRIES"`
+	}
+	vars, err := goagi.EncodeVariables("IVR_", Caller{"1234", 2})
+	// map[IVR_ACCOUNT:1234 IVR_TRIES:2]
+*/
+func EncodeVariables(prefix string, v interface{}) (map[string]string, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, ErrArgument.Msg("struct expected, got %T", v)
+	}
+	vars := make(map[string]string)
+	for name, field := range structFields(prefix, rv) {
+		val, err := encodeValue(field)
+		if err != nil {
+			return nil, err
+		}
+		vars[name] = val
+	}
+	return vars, nil
+}
+
+// DecodeVariables sets fields of struct pointed by v from channel variables
+// with prefix. Fields without variable are not changed.
+func DecodeVariables(vars map[string]string, prefix string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return ErrArgument.Msg("pointer to struct expected, got %T", v)
+	}
+	for name, field := range structFields(prefix, rv.Elem()) {
+		val, ok := vars[name]
+		if !ok {
+			continue
+		}
+		if err := decodeVariable(field, name, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetStruct sets struct fields as channel variables with prefix.
+// See EncodeVariables.
+func (agi *AGI) SetStruct(prefix string, v interface{}) error {
+	vars, err := EncodeVariables(prefix, v)
+	if err != nil {
+		return err
+	}
+	return agi.SetVariables(vars)
+}
+
+// GetStruct reads channel variables with prefix into struct pointed by v.
+// See DecodeVariables.
+func (agi *AGI) GetStruct(prefix string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return ErrArgument.Msg("pointer to struct expected, got %T", v)
+	}
+	fields := structFields(prefix, rv.Elem())
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	vars, err := agi.GetVariables(names...)
+	if err != nil {
+		return err
+	}
+	return DecodeVariables(vars, prefix, v)
+}
+
+// structFields maps variable names to exported struct fields
+func structFields(prefix string, rv reflect.Value) map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("agi"); ok {
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		fields[prefix+name] = rv.Field(i)
+	}
+	return fields
+}
+
+func encodeValue(v reflect.Value) (string, error) {
+	switch v.Type() {
+	case timeType:
+		return v.Interface().(time.Time).Format(time.RFC3339), nil
+	case durationType:
+		d := v.Interface().(time.Duration)
+		return strconv.FormatFloat(d.Seconds(), 'f', -1, 64), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		if v.Bool() {
+			return "1", nil
+		}
+		return "0", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	}
+	return "", ErrArgument.Msg("unsupported variable type %s", v.Type())
+}
+
+// decodeVariable decodes value of variable name. Invalid value returns
+// ErrVariable with the name, unsupported type returns ErrArgument.
+func decodeVariable(v reflect.Value, name, s string) error {
+	err := decodeValue(v, s)
+	if err == nil || errors.Is(err, ErrArgument) {
+		return err
+	}
+	return ErrVariable.Msg("%s: %s", name, err)
+}
+
+func decodeValue(v reflect.Value, s string) error {
+	switch v.Type() {
+	case timeType:
+		t, err := parseTime(s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		d, err := parseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := parseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(s), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(s), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return ErrArgument.Msg("unsupported variable type %s", v.Type())
+	}
+	return nil
+}
+
+// parseBool accepts the same values as Asterisk ast_true/ast_false
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "yes", "true", "y", "t", "on":
+		return true, nil
+	case "0", "no", "false", "n", "f", "off", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", s)
+}
+
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	sec, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", s)
+	}
+	return time.Unix(0, int64(sec*float64(time.Second))), nil
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if sec, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(sec * float64(time.Second)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,195 @@
+package goagi
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVariable(t *testing.T) {
+	agi, buf := mockAGI("200 result=1 (alice)")
+	val, ok, err := agi.Variable("CALLER")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "alice", val)
+	assert.Equal(t, "GET VARIABLE CALLER\n", buf.String())
+
+	agi, _ = mockAGI("200 result=1 ()")
+	val, ok, err = agi.Variable("EMPTY")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "", val)
+
+	agi, _ = mockAGI("200 result=0")
+	val, ok, err = agi.Variable("UNSET")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "", val)
+
+	agi, _ = mockAGI("511 Command Not Permitted on a dead channel")
+	_, _, err = agi.Variable("FOO")
+	assert.True(t, errors.Is(err, ErrCommand))
+}
+
+func TestGetVar(t *testing.T) {
+	agi, _ := mockAGI("200 result=1 (3)")
+	n, ok, err := GetVar[int](agi, "RETRIES")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 3, n)
+
+	agi, _ = mockAGI("200 result=1 (yes)")
+	b, ok, err := GetVar[bool](agi, "VIP")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.True(t, b)
+
+	agi, _ = mockAGI("200 result=1 (1.5)")
+	d, _, err := GetVar[time.Duration](agi, "WAIT")
+	assert.Nil(t, err)
+	assert.Equal(t, 1500*time.Millisecond, d)
+
+	agi, _ = mockAGI("200 result=1 (2m)")
+	d, _, err = GetVar[time.Duration](agi, "WAIT")
+	assert.Nil(t, err)
+	assert.Equal(t, 2*time.Minute, d)
+
+	agi, _ = mockAGI("200 result=1 (1700000000)")
+	tm, _, err := GetVar[time.Time](agi, "EPOCH")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1700000000), tm.Unix())
+
+	agi, _ = mockAGI("200 result=1 (2024-01-02T03:04:05Z)")
+	tm, _, err = GetVar[time.Time](agi, "STARTED")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), tm)
+
+	agi, _ = mockAGI("200 result=0")
+	n, ok, err = GetVar[int](agi, "UNSET")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 0, n)
+}
+
+func TestGetVarInvalid(t *testing.T) {
+	agi, _ := mockAGI("200 result=1 (abc)")
+	_, ok, err := GetVar[int](agi, "RETRIES")
+	assert.True(t, ok)
+	assert.True(t, errors.Is(err, ErrVariable))
+	assert.Contains(t, err.Error(), "RETRIES")
+
+	agi, _ = mockAGI("200 result=1 (maybe)")
+	_, _, err = GetVar[bool](agi, "VIP")
+	assert.True(t, errors.Is(err, ErrVariable))
+	assert.Equal(t, `Channel variable: VIP: invalid boolean "maybe"`, err.Error())
+
+	agi, _ = mockAGI("200 result=1 (soon)")
+	_, _, err = GetVar[time.Duration](agi, "WAIT")
+	assert.Equal(t, `Channel variable: WAIT: invalid duration "soon"`, err.Error())
+}
+
+func TestSetVar(t *testing.T) {
+	tests := []struct {
+		set  func(*AGI) error
+		want string
+	}{
+		{func(a *AGI) error { return SetVar(a, "S", "foo bar") }, `SET VARIABLE S "foo bar"` + "\n"},
+		{func(a *AGI) error { return SetVar(a, "N", 42) }, `SET VARIABLE N "42"` + "\n"},
+		{func(a *AGI) error { return SetVar(a, "B", true) }, `SET VARIABLE B "1"` + "\n"},
+		{func(a *AGI) error { return SetVar(a, "B", false) }, `SET VARIABLE B "0"` + "\n"},
+		{func(a *AGI) error { return SetVar(a, "D", 90*time.Second) }, `SET VARIABLE D "90"` + "\n"},
+		{func(a *AGI) error {
+			return SetVar(a, "T", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+		}, `SET VARIABLE T "2024-01-02T03:04:05Z"` + "\n"},
+	}
+	for _, tc := range tests {
+		agi, buf := mockAGI(respOk)
+		assert.Nil(t, tc.set(agi))
+		assert.Equal(t, tc.want, buf.String())
+	}
+
+	agi, _ := mockAGI("510 Invalid or unknown command")
+	err := SetVar(agi, "N", 1)
+	assert.True(t, errors.Is(err, ErrCommand))
+}
+
+func TestSetVariables(t *testing.T) {
+	agi, buf := mockAGIScript(respOk, respOk, respOk)
+	err := agi.SetVariables(map[string]string{"C": "3", "A": "1", "B": "2"})
+	assert.Nil(t, err)
+	assert.Equal(t,
+		"SET VARIABLE A \"1\"\nSET VARIABLE B \"2\"\nSET VARIABLE C \"3\"\n",
+		buf.String())
+
+	agi, buf = mockAGIScript(respOk, "511 Command Not Permitted on a dead channel")
+	err = agi.SetVariables(map[string]string{"C": "3", "A": "1", "B": "2"})
+	assert.True(t, errors.Is(err, ErrCommand))
+	assert.Equal(t, "SET VARIABLE A \"1\"\nSET VARIABLE B \"2\"\n", buf.String())
+}
+
+func TestGetVariables(t *testing.T) {
+	agi, buf := mockAGIScript("200 result=1 (1)", "200 result=0", "200 result=1 ()")
+	vars, err := agi.GetVariables("A", "B", "C")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"A": "1", "C": ""}, vars)
+	assert.Equal(t, "GET VARIABLE A\nGET VARIABLE B\nGET VARIABLE C\n", buf.String())
+}
+
+type testVarStruct struct {
+	Account string        `agi:"ACCOUNT"`
+	Tries   int           `agi:"TRIES"`
+	VIP     bool          `agi:"VIP"`
+	Wait    time.Duration `agi:"WAIT"`
+	Balance float64
+	Skip    string `agi:"-"`
+	private string
+}
+
+func TestEncodeDecodeVariables(t *testing.T) {
+	in := testVarStruct{"1234", 2, true, 3 * time.Second, 10.5, "skip", "private"}
+	vars, err := EncodeVariables("IVR_", &in)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"IVR_ACCOUNT": "1234",
+		"IVR_TRIES":   "2",
+		"IVR_VIP":     "1",
+		"IVR_WAIT":    "3",
+		"IVR_Balance": "10.5",
+	}, vars)
+
+	out := testVarStruct{Skip: "keep"}
+	assert.Nil(t, DecodeVariables(vars, "IVR_", &out))
+	assert.Equal(t, testVarStruct{"1234", 2, true, 3 * time.Second, 10.5, "keep", ""}, out)
+
+	_, err = EncodeVariables("IVR_", "string")
+	assert.True(t, errors.Is(err, ErrArgument))
+	assert.True(t, errors.Is(DecodeVariables(vars, "IVR_", out), ErrArgument))
+
+	err = DecodeVariables(map[string]string{"IVR_TRIES": "x"}, "IVR_", &out)
+	assert.True(t, errors.Is(err, ErrVariable))
+
+	_, err = EncodeVariables("", struct{ C chan int }{})
+	assert.True(t, errors.Is(err, ErrArgument))
+}
+
+func TestSetGetStruct(t *testing.T) {
+	agi, buf := mockAGIScript(respOk, respOk, respOk, respOk, respOk)
+	err := agi.SetStruct("IVR_", testVarStruct{Account: "99", VIP: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "SET VARIABLE IVR_ACCOUNT \"99\"\n"+
+		"SET VARIABLE IVR_Balance \"0\"\n"+
+		"SET VARIABLE IVR_TRIES \"0\"\n"+
+		"SET VARIABLE IVR_VIP \"1\"\n"+
+		"SET VARIABLE IVR_WAIT \"0\"\n", buf.String())
+
+	agi, buf = mockAGIScript(
+		"200 result=1 (99)", "200 result=0", "200 result=1 (4)",
+		"200 result=1 (no)", "200 result=1 (0.25)")
+	var out testVarStruct
+	assert.Nil(t, agi.GetStruct("IVR_", &out))
+	assert.Equal(t, testVarStruct{Account: "99", Tries: 4, Wait: 250 * time.Millisecond}, out)
+	assert.Equal(t, "GET VARIABLE IVR_ACCOUNT\nGET VARIABLE IVR_Balance\n"+
+		"GET VARIABLE IVR_TRIES\nGET VARIABLE IVR_VIP\nGET VARIABLE IVR_WAIT\n", buf.String())
+}